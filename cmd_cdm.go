@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"starlink/pkg/cdm"
+	"starlink/pkg/conjunction"
+	"starlink/pkg/model"
+	"starlink/pkg/tle"
+)
+
+// cdmOriginator is the ORIGINATOR of the CDMs written from screening results
+const cdmOriginator = "TLE-ORBIT-SOLVER"
+
+// writeCDMs writes one CDM per conjunction into the directory given by --cdm-out, in the
+// encoding given by --cdm-format (kvn by default), named after the message ID
+func writeCDMs(conjunctions []conjunction.Conjunction, flagValues map[string]string) error {
+	dir := flagValues["--cdm-out"]
+	format := cdm.FormatKVN
+	if value, ok := flagValues["--cdm-format"]; ok {
+		var err error
+		if format, err = cdm.ParseFormat(value); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create CDM directory: %w", err)
+	}
+
+	created := time.Now()
+	for _, c := range conjunctions {
+		m := cdm.FromConjunction(c, cdmOriginator, created)
+		file, err := os.Create(filepath.Join(dir, m.MessageID+format.Extension()))
+		if err != nil {
+			return fmt.Errorf("failed to create CDM file: %w", err)
+		}
+		err = cdm.Write(file, m, format)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write CDM: %w", err)
+		}
+	}
+	fmt.Printf("Wrote %d CDMs to %s\n", len(conjunctions), dir)
+	return nil
+}
+
+// runCompareCDM loads the CDM given by --cdm, finds its two objects in the catalog (or
+// the --against TLE file) by catalog number or name, and compares the reported encounter
+// with the closest approach and probability of collision from our own elements
+func runCompareCDM(tleData string, flagValues map[string]string, displayLocation *time.Location) error {
+	path, ok := flagValues["--cdm"]
+	if !ok {
+		return errors.New("--compare-cdm requires --cdm FILE")
+	}
+	assessment, err := parseAssessmentFlags(flagValues)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open CDM: %w", err)
+	}
+	m, err := cdm.Read(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	catalog := tle.ParseCatalog(tleData)
+	if path, ok := flagValues["--against"]; ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read --against TLE file: %w", err)
+		}
+		catalog = append(catalog, tle.ParseCatalog(string(data))...)
+	}
+	var sats [2]model.Satellite
+	for i, o := range m.Objects {
+		sat, ok := findCDMObject(catalog, o)
+		if !ok {
+			return fmt.Errorf("OBJECT%d %s (%s) not found in the TLE data", i+1, o.Name, o.Designator)
+		}
+		sats[i] = sat
+	}
+
+	c, err := conjunction.Closest(sats[0], sats[1], m.TCA)
+	if err != nil {
+		return err
+	}
+	conjunctions := []conjunction.Conjunction{c}
+	conjunction.Assess(conjunctions, assessment)
+	c = conjunctions[0]
+
+	fmt.Printf("CDM %s from %s, created %s\n", m.MessageID, m.Originator,
+		m.CreationDate.In(displayLocation).Format(time.RFC3339))
+	fmt.Printf("OBJECT1: %s (%s)\nOBJECT2: %s (%s)\n\n", sats[0].Name, m.Objects[0].Designator,
+		sats[1].Name, m.Objects[1].Designator)
+
+	fmt.Printf("%-12s %26s %26s %14s\n", "", "CDM", "Screening", "Difference")
+	fmt.Printf("%-12s %26s %26s %14.3f\n", "TCA", m.TCA.In(displayLocation).Format(passTimeFormat),
+		c.TCA.In(displayLocation).Format(passTimeFormat), c.TCA.Sub(m.TCA).Seconds())
+	compareRow("Miss [km]", m.MissDistance/1000, c.MissDistance)
+	if m.HasRelativeState {
+		compareRow("R [km]", m.RelativePosition.X/1000, c.Radial)
+		compareRow("T [km]", m.RelativePosition.Y/1000, c.InTrack)
+		compareRow("N [km]", m.RelativePosition.Z/1000, c.CrossTrack)
+	}
+	if m.RelativeSpeed != 0 {
+		compareRow("V [km/s]", m.RelativeSpeed/1000, c.RelativeSpeed)
+	}
+	if m.ProbabilityMethod != "" {
+		fmt.Printf("%-12s %26.3e %26.3e %14s\n", "Pc", m.CollisionProbability, c.Probability,
+			probabilityRatio(m.CollisionProbability, c.Probability))
+		fmt.Printf("%-12s %26s %26s\n", "Pc method", m.ProbabilityMethod, cdm.ProbabilityMethod)
+	} else {
+		fmt.Printf("%-12s %26s %26.3e\n", "Pc", "-", c.Probability)
+	}
+
+	return nil
+}
+
+// compareRow prints a quantity from the CDM and from our screening with their difference
+func compareRow(label string, reported, computed float64) {
+	fmt.Printf("%-12s %26.3f %26.3f %14.3f\n", label, reported, computed, computed-reported)
+}
+
+// findCDMObject looks up a CDM object by catalog number, then by name
+func findCDMObject(catalog []model.Satellite, o cdm.Object) (model.Satellite, bool) {
+	designator := strings.TrimLeft(o.Designator, "0")
+	for _, sat := range catalog {
+		if designator != "" && strings.TrimLeft(sat.Elements.CatalogNumber, "0") == designator {
+			return sat, true
+		}
+	}
+	for _, sat := range catalog {
+		if o.Name != "" && strings.EqualFold(sat.Name, o.Name) {
+			return sat, true
+		}
+	}
+	return model.Satellite{}, false
+}
+
+// probabilityRatio formats the ratio of our probability to the reported one
+func probabilityRatio(reported, computed float64) string {
+	if reported <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("x%.3g", computed/reported)
+}
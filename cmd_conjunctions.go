@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/conjunction"
+	"starlink/pkg/model"
+	"starlink/pkg/tle"
+	"starlink/pkg/vecmath"
+)
+
+// runConjunctions screens for close approaches and prints a ranked report. With --all
+// every pair in the catalog is screened; otherwise the selected satellites are screened
+// against the whole catalog. Objects from the TLE file given by --against are added to
+// the secondaries in both cases. The window defaults to one hour from the evaluation time.
+// Each conjunction is assessed for its probability of collision and the report is ranked
+// by that probability, flagging those above the warning and alert thresholds.
+func runConjunctions(targets []model.Satellite, tleData string, allSatellites bool, flagValues map[string]string,
+	evaluationTime time.Time, displayLocation *time.Location) error {
+	opts, err := parseConjunctionFlags(flagValues, evaluationTime)
+	if err != nil {
+		return err
+	}
+	assessment, err := parseAssessmentFlags(flagValues)
+	if err != nil {
+		return err
+	}
+
+	var secondaries []model.Satellite
+	if !allSatellites {
+		// The catalog includes the primaries, which Screen pairs only once
+		secondaries = tle.ParseCatalog(tleData)
+	}
+	if path, ok := flagValues["--against"]; ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read --against TLE file: %w", err)
+		}
+		secondaries = append(secondaries, tle.ParseCatalog(string(data))...)
+	}
+
+	fmt.Printf("Screening %d primaries against %s from %s to %s (threshold %.1f km)\n",
+		len(targets), secondaryDescription(secondaries),
+		opts.Start.In(displayLocation).Format(time.RFC3339),
+		opts.Stop.In(displayLocation).Format(time.RFC3339), opts.Threshold)
+
+	// Stop screening on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	conjunctions, stats, err := conjunction.Screen(ctx, targets, secondaries, opts)
+	if err != nil {
+		return err
+	}
+
+	conjunction.Assess(conjunctions, assessment)
+	conjunction.RankByProbability(conjunctions)
+
+	fmt.Printf("%d objects, %d pairs, %d rejected by apogee/perigee filter, %d candidates refined",
+		stats.Objects, stats.PairsTotal, stats.PairsFiltered, stats.Candidates)
+	if stats.SkippedObjects > 0 {
+		fmt.Printf(", %d objects skipped", stats.SkippedObjects)
+	}
+	fmt.Printf("\n\n%4s %-24s %-24s %-24s %9s %9s %9s %9s %9s %9s %s\n", "Rank", "Primary", "Secondary", "TCA",
+		"Miss [km]", "R [km]", "I [km]", "C [km]", "V [km/s]", "Pc", "Level")
+	var warnings, alerts int
+	for i, c := range conjunctions {
+		level := ""
+		switch c.Level {
+		case conjunction.LevelAlert:
+			level = "ALERT"
+			alerts++
+		case conjunction.LevelWarning:
+			level = "warning"
+			warnings++
+		}
+		fmt.Printf("%4d %-24s %-24s %-24s %9.3f %9.3f %9.3f %9.3f %9.3f %9.2e %s\n", i+1, c.Primary.Name, c.Secondary.Name,
+			c.TCA.In(displayLocation).Format(passTimeFormat), c.MissDistance, c.Radial, c.InTrack, c.CrossTrack, c.RelativeSpeed,
+			c.Probability, level)
+	}
+	fmt.Printf("\nFound %d conjunctions, %d alerts, %d warnings.\n", len(conjunctions), alerts, warnings)
+
+	if _, ok := flagValues["--cdm-out"]; ok {
+		if err := writeCDMs(conjunctions, flagValues); err != nil {
+			return err
+		}
+	}
+
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+
+		writer := conjunction.NewCSVWriter(file)
+		for i, c := range conjunctions {
+			if err := writer.Write(i+1, c); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	}
+
+	return nil
+}
+
+// secondaryDescription describes the secondary catalog for the report header
+func secondaryDescription(secondaries []model.Satellite) string {
+	if len(secondaries) == 0 {
+		return "each other"
+	}
+	return fmt.Sprintf("%d secondaries", len(secondaries))
+}
+
+// parseConjunctionFlags builds the screening options from command line values
+func parseConjunctionFlags(flagValues map[string]string, evaluationTime time.Time) (conjunction.Options, error) {
+	var opts conjunction.Options
+
+	start, stop, err := parseWindowFlags(flagValues, evaluationTime, time.Hour)
+	if err != nil {
+		return opts, err
+	}
+	opts.Start, opts.Stop = start, stop
+
+	if value, ok := flagValues["--threshold"]; ok {
+		if opts.Threshold, err = strconv.ParseFloat(value, 64); err != nil || opts.Threshold <= 0 {
+			return opts, fmt.Errorf("invalid --threshold %q", value)
+		}
+	}
+	if value, ok := flagValues["--step"]; ok {
+		if opts.Step, err = time.ParseDuration(value); err != nil {
+			return opts, fmt.Errorf("invalid --step: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// parseAssessmentFlags builds the collision probability options from command line
+// values. --covariance gives constant radial, in-track and cross-track sigmas in km in
+// place of the TLE error model, and --hbr the combined hard-body radius in meters.
+func parseAssessmentFlags(flagValues map[string]string) (conjunction.AssessOptions, error) {
+	var opts conjunction.AssessOptions
+	var err error
+
+	if value, ok := flagValues["--covariance"]; ok {
+		fields := strings.Split(value, ",")
+		if len(fields) != 3 {
+			return opts, fmt.Errorf("invalid --covariance %q: expected radial,in-track,cross-track sigmas in km", value)
+		}
+		var sigma [3]float64
+		for i, field := range fields {
+			if sigma[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil || sigma[i] < 0 {
+				return opts, fmt.Errorf("invalid --covariance %q", value)
+			}
+		}
+		opts.Covariance = conjunction.ConstantCovariance(vecmath.Vec3{X: sigma[0], Y: sigma[1], Z: sigma[2]})
+	}
+	if value, ok := flagValues["--hbr"]; ok {
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil || radius <= 0 {
+			return opts, fmt.Errorf("invalid --hbr %q", value)
+		}
+		opts.HardBodyRadius = radius / 1000
+	}
+	if value, ok := flagValues["--pc-warning"]; ok {
+		if opts.Thresholds.Warning, err = strconv.ParseFloat(value, 64); err != nil || opts.Thresholds.Warning <= 0 {
+			return opts, fmt.Errorf("invalid --pc-warning %q", value)
+		}
+	}
+	if value, ok := flagValues["--pc-alert"]; ok {
+		if opts.Thresholds.Alert, err = strconv.ParseFloat(value, 64); err != nil || opts.Thresholds.Alert <= 0 {
+			return opts, fmt.Errorf("invalid --pc-alert %q", value)
+		}
+	}
+	warning, alert := opts.Thresholds.Warning, opts.Thresholds.Alert
+	if warning == 0 {
+		warning = conjunction.DefaultWarningThreshold
+	}
+	if alert == 0 {
+		alert = conjunction.DefaultAlertThreshold
+	}
+	if warning > alert {
+		return opts, fmt.Errorf("--pc-warning %g is above --pc-alert %g", warning, alert)
+	}
+
+	return opts, nil
+}
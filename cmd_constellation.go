@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"starlink/pkg/constellation"
+	"starlink/pkg/model"
+)
+
+// runConstellation classifies the satellites into shells, planes and slots at the
+// evaluation time and prints the shells and the satellites migrating between planes,
+// followed by the assignment table, which is written as CSV to --out instead when given
+func runConstellation(satellites []model.Satellite, flagValues map[string]string, evaluationTime time.Time,
+	displayLocation *time.Location) error {
+	c := constellation.Classify(satellites, evaluationTime, constellation.Options{})
+
+	fmt.Printf("Constellation structure at %s: %d satellites in %d shells\n",
+		evaluationTime.In(displayLocation).Format(time.RFC3339), len(c.Members), len(c.Shells))
+	if len(c.Shells) == 0 {
+		fmt.Printf("No shell has %d satellites; use --all to classify the whole catalog\n",
+			constellation.DefaultMinShellSize)
+		return nil
+	}
+
+	fmt.Printf("\n%-6s %9s %10s %6s %7s %12s %16s\n", "Shell", "Incl [°]", "Alt [km]", "Sats",
+		"Planes", "Slots/plane", "RAAN rate [°/d]")
+	inShells := 0
+	for _, s := range c.Shells {
+		inShells += len(s.Members)
+		fmt.Printf("%-6d %9.3f %10.1f %6d %7d %12d %16.4f\n", s.Number, s.Inclination, s.Altitude,
+			len(s.Members), len(s.Planes), s.SlotsPerPlane, s.RaanRate)
+	}
+	fmt.Printf("%d satellites in transit outside the shells\n", len(c.Members)-inShells)
+
+	migrating := c.Migrating()
+	fmt.Printf("\n%d satellites migrating between planes\n", len(migrating))
+	if len(migrating) > 0 {
+		fmt.Printf("%-24s %6s %6s %7s %10s %16s\n", "Satellite", "Shell", "Plane", "Target", "Alt [km]",
+			"Drift [°/d]")
+		for _, i := range migrating {
+			m := c.Members[i]
+			fmt.Printf("%-24s %6d %6s %7s %10.1f %16.4f\n", m.Name, m.Shell, planeLabel(m.Plane),
+				planeLabel(m.TargetPlane), m.Altitude, m.RaanDrift)
+		}
+	}
+
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		if err := constellation.WriteCSV(file, c); err != nil {
+			return fmt.Errorf("failed to write assignments: %w", err)
+		}
+		fmt.Printf("\nAssignments written to %s\n", path)
+		return nil
+	}
+
+	fmt.Printf("\n%-24s %6s %6s %5s %9s %9s %10s %9s %9s\n", "Satellite", "Shell", "Plane", "Slot",
+		"Offset", "Incl [°]", "Alt [km]", "RAAN [°]", "AoL [°]")
+	for _, i := range c.Sorted() {
+		m := c.Members[i]
+		fmt.Printf("%-24s %6s %6s %5s %9.2f %9.3f %10.1f %9.3f %9.3f\n", m.Name, planeLabel(m.Shell),
+			planeLabel(m.Plane), planeLabel(m.Slot), m.SlotOffset, m.Inclination, m.Altitude, m.Raan,
+			m.ArgumentOfLatitude)
+	}
+	return nil
+}
+
+// planeLabel formats a shell, plane or slot number, with "-" for none
+func planeLabel(number int) string {
+	if number == 0 {
+		return "-"
+	}
+	return fmt.Sprint(number)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/decay"
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+)
+
+// defaultDecayWithin is the window within which --decay flags re-entries [day]
+const defaultDecayWithin = 30.0
+
+// maxDecayListing is the number of satellites above which --decay lists only the
+// flagged re-entries and counts the satellites without a prediction
+const maxDecayListing = 50
+
+// runDecay predicts the re-entry of each satellite from its element set and prints
+// them in re-entry order, flagging those that re-enter within --within days of the
+// evaluation time; for more than maxDecayListing satellites only those are listed.
+// --decay-method selects the prediction method, --flux the solar activity as
+// F10.7[,Ap], --uncertainty the relative density uncertainty of the bounds and
+// --area-to-mass a ballistic coefficient (with the default drag coefficient) in place
+// of the one derived from the element set. The numerical method, which propagates
+// each satellite for up to two years, is refused with --all.
+func runDecay(satellites []model.Satellite, allSatellites bool, flagValues map[string]string,
+	evaluationTime time.Time, displayLocation *time.Location) error {
+	opts, within, err := parseDecayFlags(flagValues)
+	if err != nil {
+		return err
+	}
+	if allSatellites && opts.Method == decay.MethodNumerical {
+		return errors.New("--decay-method numerical is too slow for --all; select satellites or use ndot or bstar")
+	}
+
+	type prediction struct {
+		name     string
+		estimate decay.Estimate
+	}
+	var predictions []prediction
+	var failures []string
+	for _, sat := range satellites {
+		estimate, err := decay.Predict(sat.Elements, opts)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sat.Name, err))
+			continue
+		}
+		predictions = append(predictions, prediction{sat.Name, estimate})
+	}
+	// Re-entries within the horizon first, earliest first
+	sort.SliceStable(predictions, func(i, j int) bool {
+		a, b := predictions[i].estimate, predictions[j].estimate
+		if a.Decays != b.Decays {
+			return a.Decays
+		}
+		return a.Reentry.Before(b.Reentry)
+	})
+
+	fmt.Printf("Decay prediction (%s, F10.7 %.0f, Ap %.0f, density ±%.0f%%), flagging re-entries before %s\n",
+		opts.Method, opts.Atmosphere.F107, opts.Atmosphere.Ap, opts.DensityUncertainty*100,
+		evaluationTime.Add(within).In(displayLocation).Format(time.RFC3339))
+	fmt.Printf("%-24s %8s %12s %10s  %-20s %-20s %-20s %9s\n", "Satellite", "Alt [km]", "da [km/day]",
+		"B [m2/kg]", "Re-entry", "Earliest", "Latest", "Days")
+
+	listAll := len(satellites) <= maxDecayListing
+	flagged := 0
+	for _, p := range predictions {
+		e := p.estimate
+		marker := ""
+		if e.Within(evaluationTime, within) {
+			marker = "  RE-ENTRY"
+			flagged++
+		} else if !listAll {
+			continue
+		}
+		reentry, latest, days := "beyond horizon", "beyond horizon", "-"
+		if e.Decays {
+			reentry = formatDecayTime(e.Reentry, displayLocation)
+			days = fmt.Sprintf("%9.1f", e.Reentry.Sub(evaluationTime).Hours()/24)
+		}
+		if !e.Latest.IsZero() {
+			latest = formatDecayTime(e.Latest, displayLocation)
+		}
+		earliest := "beyond horizon"
+		if !e.Earliest.IsZero() {
+			earliest = formatDecayTime(e.Earliest, displayLocation)
+		}
+		fmt.Printf("%-24s %8.1f %12.4f %10.4f  %-20s %-20s %-20s %9s%s\n", p.name, e.Altitude, e.DecayRate,
+			e.BallisticCoefficient, reentry, earliest, latest, days, marker)
+	}
+
+	fmt.Printf("\n%d of %d satellites expected to re-enter within %.0f days\n", flagged, len(satellites),
+		within.Hours()/24)
+	if len(failures) > 0 && !listAll {
+		fmt.Printf("%d satellites without a prediction, mostly without decay in their element sets\n", len(failures))
+	} else if len(failures) > 0 {
+		fmt.Printf("%d satellites without a prediction:\n", len(failures))
+		for _, failure := range failures {
+			fmt.Printf("  %s\n", failure)
+		}
+	}
+	return nil
+}
+
+// formatDecayTime formats a re-entry time to the minute
+func formatDecayTime(t time.Time, displayLocation *time.Location) string {
+	return t.In(displayLocation).Format("2006-01-02 15:04 MST")
+}
+
+// parseDecayFlags parses the decay prediction options and the flagging window
+func parseDecayFlags(flagValues map[string]string) (decay.Options, time.Duration, error) {
+	opts := decay.Options{
+		Atmosphere:         numerical.SolarFlux{F107: numerical.DefaultF107, Ap: numerical.DefaultAp},
+		DensityUncertainty: decay.DefaultDensityUncertainty,
+	}
+	var err error
+
+	if value, ok := flagValues["--decay-method"]; ok {
+		if opts.Method, err = decay.ParseMethod(value); err != nil {
+			return opts, 0, err
+		}
+	}
+	if value, ok := flagValues["--flux"]; ok {
+		fields := strings.Split(value, ",")
+		if len(fields) > 2 {
+			return opts, 0, fmt.Errorf("invalid --flux %q: expected F10.7[,Ap]", value)
+		}
+		if opts.Atmosphere.F107, err = strconv.ParseFloat(strings.TrimSpace(fields[0]), 64); err != nil || opts.Atmosphere.F107 <= 0 {
+			return opts, 0, fmt.Errorf("invalid --flux %q", value)
+		}
+		if len(fields) == 2 {
+			if opts.Atmosphere.Ap, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64); err != nil || opts.Atmosphere.Ap <= 0 {
+				return opts, 0, fmt.Errorf("invalid --flux %q", value)
+			}
+		}
+	}
+	if value, ok := flagValues["--uncertainty"]; ok {
+		if opts.DensityUncertainty, err = strconv.ParseFloat(value, 64); err != nil ||
+			opts.DensityUncertainty <= 0 || opts.DensityUncertainty >= 1 {
+			return opts, 0, fmt.Errorf("invalid --uncertainty %q (expected a fraction between 0 and 1)", value)
+		}
+	}
+	if value, ok := flagValues["--area-to-mass"]; ok {
+		areaToMass, err := strconv.ParseFloat(value, 64)
+		if err != nil || areaToMass <= 0 {
+			return opts, 0, fmt.Errorf("invalid --area-to-mass %q", value)
+		}
+		opts.BallisticCoefficient = areaToMass * numerical.DefaultDragCoefficient
+	}
+
+	within := defaultDecayWithin
+	if value, ok := flagValues["--within"]; ok {
+		if within, err = strconv.ParseFloat(value, 64); err != nil || within < 0 {
+			return opts, 0, fmt.Errorf("invalid --within %q", value)
+		}
+	}
+	return opts, time.Duration(within * 24 * float64(time.Hour)), nil
+}
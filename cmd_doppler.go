@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"starlink/pkg/doppler"
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/passes"
+)
+
+// runDoppler predicts passes of each satellite over the station and writes a Doppler
+// tuning table for every pass as CSV to the file given by --out, or to standard output.
+// Passes are searched as for --passes; --step sets the table interval (default 1s).
+func runDoppler(satellites []model.Satellite, station *observer.Station, flagValues map[string]string,
+	evaluationTime time.Time) error {
+	if station == nil {
+		return errors.New("--doppler requires --observer lat,lon,alt")
+	}
+
+	link, opts, step, err := parseDopplerFlags(flagValues, evaluationTime, station.Observer.Mask != nil)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := doppler.NewCSVWriter(out, link)
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sat.Name, err)
+			continue
+		}
+
+		found, err := passes.FindPasses(sat.Name, p, station, opts)
+		if err != nil {
+			return err
+		}
+
+		for i, pass := range found {
+			samples, err := doppler.Curve(p, station, link, pass.AOS.Time, pass.LOS.Time, step)
+			if err != nil {
+				return err
+			}
+			for _, sample := range samples {
+				if err := writer.Write(sat.Name, i+1, sample); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return writer.Flush()
+}
+
+// parseDopplerFlags builds the link, the pass search options and the table interval
+// from command line values. At least one of --downlink and --uplink is required.
+func parseDopplerFlags(flagValues map[string]string, evaluationTime time.Time, hasMask bool) (doppler.Link, passes.Options, time.Duration, error) {
+	var link doppler.Link
+	var err error
+
+	if value, ok := flagValues["--downlink"]; ok {
+		if link.Downlink, err = doppler.ParseFrequency(value); err != nil {
+			return link, passes.Options{}, 0, fmt.Errorf("invalid --downlink: %w", err)
+		}
+	}
+	if value, ok := flagValues["--uplink"]; ok {
+		if link.Uplink, err = doppler.ParseFrequency(value); err != nil {
+			return link, passes.Options{}, 0, fmt.Errorf("invalid --uplink: %w", err)
+		}
+	}
+	if link.Downlink == 0 && link.Uplink == 0 {
+		return link, passes.Options{}, 0, errors.New("--doppler requires --downlink or --uplink")
+	}
+
+	opts, err := parsePassFlags(flagValues, evaluationTime, hasMask)
+	if err != nil {
+		return link, opts, 0, err
+	}
+
+	// --step is the table interval here; passes are searched with the default step
+	step := time.Second
+	if opts.Step > 0 {
+		step, opts.Step = opts.Step, 0
+	}
+
+	return link, opts, step, nil
+}
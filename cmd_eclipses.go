@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"starlink/pkg/eclipse"
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// runEclipses finds the shadow intervals of each satellite and prints them.
+// The window defaults to 24 hours from the evaluation time and the shadow model
+// to conical.
+func runEclipses(satellites []model.Satellite, flagValues map[string]string,
+	evaluationTime time.Time, displayLocation *time.Location) error {
+	opts, err := parseEclipseFlags(flagValues, evaluationTime)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Eclipses from %s to %s (%s shadow)\n\n",
+		opts.Start.In(displayLocation).Format(time.RFC3339),
+		opts.Stop.In(displayLocation).Format(time.RFC3339), opts.Model)
+
+	total := 0
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", sat.Name, err)
+			continue
+		}
+
+		intervals, err := eclipse.FindEclipses(p, opts)
+		if err != nil {
+			return err
+		}
+		if len(intervals) == 0 {
+			continue
+		}
+
+		fmt.Printf("--- %s: %d eclipse(s) ---\n", sat.Name, len(intervals))
+		for _, interval := range intervals {
+			printEclipse(interval, displayLocation)
+		}
+		total += len(intervals)
+	}
+
+	fmt.Printf("Found %d eclipses.\n", total)
+	return nil
+}
+
+// printEclipse prints the entry and exit times of a single shadow interval
+func printEclipse(interval eclipse.Interval, displayLocation *time.Location) {
+	entryNote, exitNote := "", ""
+	if interval.StartTruncated {
+		entryNote = " (window start)"
+	}
+	if interval.EndTruncated {
+		exitNote = " (window stop)"
+	}
+
+	fmt.Printf("  Entry %s%s\n", interval.Start.In(displayLocation).Format(passTimeFormat), entryNote)
+	if !interval.UmbraStart.IsZero() && (!interval.UmbraStart.Equal(interval.Start) || !interval.UmbraStop.Equal(interval.Stop)) {
+		fmt.Printf("  Umbra %s - %s\n",
+			interval.UmbraStart.In(displayLocation).Format(passTimeFormat),
+			interval.UmbraStop.In(displayLocation).Format(passTimeFormat))
+	}
+	fmt.Printf("  Exit  %s%s\n", interval.Stop.In(displayLocation).Format(passTimeFormat), exitNote)
+	fmt.Printf("  Duration %s\n\n", interval.Duration().Round(time.Millisecond))
+}
+
+// parseEclipseFlags builds the eclipse search options from command line values
+func parseEclipseFlags(flagValues map[string]string, evaluationTime time.Time) (eclipse.Options, error) {
+	var opts eclipse.Options
+
+	start, stop, err := parseWindowFlags(flagValues, evaluationTime, 24*time.Hour)
+	if err != nil {
+		return opts, err
+	}
+	opts.Start, opts.Stop = start, stop
+
+	if value, ok := flagValues["--shadow-model"]; ok {
+		if opts.Model, err = orbital.ParseShadowModel(value); err != nil {
+			return opts, fmt.Errorf("invalid --shadow-model: %w", err)
+		}
+	}
+	if value, ok := flagValues["--step"]; ok {
+		if opts.Step, err = time.ParseDuration(value); err != nil {
+			return opts, fmt.Errorf("invalid --step: %w", err)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// runElements prints the osculating state vector, Keplerian and equinoctial elements
+// and the derived orbit properties of each satellite at the evaluation time
+func runElements(satellites []model.Satellite, evaluationTime time.Time, displayLocation *time.Location) error {
+	fmt.Printf("Osculating elements at %s (equatorial frame)\n", evaluationTime.In(displayLocation).Format(time.RFC3339))
+
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Printf("\n%s: %v\n", sat.Name, err)
+			continue
+		}
+
+		state := p.StateECI(evaluationTime)
+		el := orbital.StateToKeplerian(state)
+		eq := orbital.KeplerianToEquinoctial(el)
+		props := el.Properties()
+
+		fmt.Printf("\n--- %s ---\n", sat.Name)
+		fmt.Printf("  Position:  %12.3f %12.3f %12.3f km\n", state.X, state.Y, state.Z)
+		fmt.Printf("  Velocity:  %12.6f %12.6f %12.6f km/s\n", state.VX, state.VY, state.VZ)
+		fmt.Printf("  Semi-major axis:      %12.3f km\n", el.SemiMajorAxis)
+		fmt.Printf("  Eccentricity:         %12.7f\n", el.Eccentricity)
+		fmt.Printf("  Inclination:          %12.4f°\n", el.Inclination)
+		fmt.Printf("  RAAN:                 %12.4f°\n", el.Raan)
+		fmt.Printf("  Argument of perigee:  %12.4f°\n", el.ArgumentOfPerigee)
+		fmt.Printf("  True anomaly:         %12.4f°\n", el.TrueAnomaly)
+		fmt.Printf("  Mean anomaly:         %12.4f°\n", el.MeanAnomaly())
+		fmt.Printf("  Argument of latitude: %12.4f°\n", el.ArgumentOfLatitude())
+		fmt.Printf("  Equinoctial h, k:     %12.7f %12.7f\n", eq.H, eq.K)
+		fmt.Printf("  Equinoctial p, q:     %12.7f %12.7f\n", eq.P, eq.Q)
+		fmt.Printf("  Mean longitude:       %12.4f°\n", eq.MeanLongitude)
+		fmt.Printf("  Perigee altitude:     %12.3f km\n", props.PerigeeAltitude)
+		fmt.Printf("  Apogee altitude:      %12.3f km\n", props.ApogeeAltitude)
+		fmt.Printf("  Period:               %12s (%.8f rev/day)\n", props.Period.Round(time.Millisecond), props.MeanMotion)
+		fmt.Printf("  Specific energy:      %12.6f km²/s²\n", props.SpecificEnergy)
+		fmt.Printf("  Angular momentum:     %12.3f km²/s\n", props.AngularMomentum)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"starlink/pkg/ephemeris"
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+	"starlink/pkg/orbital"
+)
+
+// runEphemeris generates an ephemeris table for the satellites and writes it as CSV
+// to the file given by --out, or to standard output. With --propagator numerical the
+// states are integrated from the analytic state at the window start.
+func runEphemeris(satellites []model.Satellite, flagValues map[string]string, evaluationTime time.Time) error {
+	window, frame, err := parseEphemerisFlags(flagValues, evaluationTime)
+	if err != nil {
+		return err
+	}
+	newPropagator, err := parsePropagatorFlags(flagValues, window.Start)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := ephemeris.NewCSVWriter(out, frame)
+	if err := ephemeris.GenerateWith(satellites, window, frame, newPropagator, writer.Write); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// parseEphemerisFlags builds the ephemeris window and frame from command line values.
+// The window defaults to one hour from the evaluation time sampled every minute in the
+// ECI frame. Offsets in --start are relative to the evaluation time and offsets in
+// --stop are relative to the start.
+func parseEphemerisFlags(flagValues map[string]string, evaluationTime time.Time) (ephemeris.Window, ephemeris.Frame, error) {
+	window := ephemeris.Window{Step: time.Minute}
+	frame := ephemeris.FrameECI
+	var err error
+
+	window.Start, window.Stop, err = parseWindowFlags(flagValues, evaluationTime, time.Hour)
+	if err != nil {
+		return window, frame, err
+	}
+	if value, ok := flagValues["--step"]; ok {
+		if window.Step, err = time.ParseDuration(value); err != nil {
+			return window, frame, fmt.Errorf("invalid --step: %w", err)
+		}
+	}
+	if value, ok := flagValues["--count"]; ok {
+		if window.Count, err = strconv.Atoi(value); err != nil {
+			return window, frame, fmt.Errorf("invalid --count: %w", err)
+		}
+	}
+	if value, ok := flagValues["--frame"]; ok {
+		if frame, err = ephemeris.ParseFrame(value); err != nil {
+			return window, frame, err
+		}
+	}
+
+	return window, frame, window.Validate()
+}
+
+// parsePropagatorFlags selects the propagator from command line values: the analytic
+// model by default, or with --propagator numerical an integration from start under the
+// forces of --forces, with the optional --gravity-field coefficient file, --atmosphere,
+// --area-to-mass and --shadow-model.
+func parsePropagatorFlags(flagValues map[string]string, start time.Time) (func(model.Satellite) (ephemeris.Propagator, error), error) {
+	name := flagValues["--propagator"]
+	switch name {
+	case "", "analytic":
+		return func(sat model.Satellite) (ephemeris.Propagator, error) {
+			return orbital.NewPropagator(sat.Elements)
+		}, nil
+	case "numerical":
+	default:
+		return nil, fmt.Errorf("unknown --propagator %q (expected analytic or numerical)", name)
+	}
+
+	spec := numerical.DefaultForces
+	if value, ok := flagValues["--forces"]; ok {
+		spec = value
+	}
+	forceModel, err := numerical.ParseForceModel(spec)
+	if err != nil {
+		return nil, err
+	}
+	if path, ok := flagValues["--gravity-field"]; ok {
+		if forceModel.Gravity, err = numerical.LoadGravityField(path, maxGravityDegree); err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := flagValues["--atmosphere"]; ok {
+		if forceModel.Atmosphere == nil {
+			return nil, errors.New("--atmosphere requires drag in --forces")
+		}
+		if forceModel.Atmosphere, err = numerical.ParseAtmosphere(value); err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := flagValues["--area-to-mass"]; ok {
+		if forceModel.Spacecraft.AreaToMass, err = strconv.ParseFloat(value, 64); err != nil || forceModel.Spacecraft.AreaToMass < 0 {
+			return nil, fmt.Errorf("invalid --area-to-mass %q", value)
+		}
+	}
+	if value, ok := flagValues["--shadow-model"]; ok {
+		if forceModel.Shadow, err = orbital.ParseShadowModel(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(sat model.Satellite) (ephemeris.Propagator, error) {
+		return numerical.FromElements(sat.Elements, start, forceModel.Forces(), numerical.Options{})
+	}, nil
+}
+
+// maxGravityDegree truncates gravity fields loaded from coefficient files, whose cost
+// grows with the square of the degree
+const maxGravityDegree = 20
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"starlink/pkg/ephemeris"
+	"starlink/pkg/model"
+	"starlink/pkg/tle"
+	"starlink/pkg/tlefit"
+	"starlink/pkg/util"
+)
+
+// runFitTle fits a TLE to each satellite of the ECI ephemeris CSV given by --states and
+// prints it with the fit residuals. Satellites found in the TLE data lend their catalog
+// number, international designator and drag terms. The epoch defaults to the first
+// state of each satellite; offsets in --epoch are relative to it. With --fit-drag the
+// mean motion derivative is solved for too. The TLEs are also written to --out.
+func runFitTle(tleData string, flagValues map[string]string) error {
+	path, ok := flagValues["--states"]
+	if !ok {
+		return errors.New("--fit-tle requires --states FILE")
+	}
+	records, err := ephemeris.LoadCSV(path)
+	if err != nil {
+		return err
+	}
+
+	// States of each satellite, in the order the satellites first appear
+	var names []string
+	states := make(map[string][]model.StateVector)
+	for _, r := range records {
+		if _, ok := states[r.Satellite]; !ok {
+			names = append(names, r.Satellite)
+		}
+		states[r.Satellite] = append(states[r.Satellite], r.State)
+	}
+
+	var out io.Writer
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	for _, name := range names {
+		opts := tlefit.Options{FitDrag: flagValues["--fit-drag"] != ""}
+		if value, ok := flagValues["--epoch"]; ok {
+			first := states[name][0].Time
+			for _, s := range states[name] {
+				if s.Time.Before(first) {
+					first = s.Time
+				}
+			}
+			if opts.Epoch, err = util.ParseTime(value, first); err != nil {
+				return fmt.Errorf("invalid --epoch: %w", err)
+			}
+		}
+
+		var template *model.TleOrbitalElement
+		if sats, err := tle.FindSatellites(tleData, []string{name}); err == nil && len(sats) > 0 {
+			template = sats[0].Elements
+		}
+
+		result, err := tlefit.Fit(states[name], template, opts)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", name, err)
+			continue
+		}
+		line1, line2 := tle.FormatTle(result.Elements, 0)
+
+		convergence := "converged"
+		if !result.Converged {
+			convergence = "not converged"
+		}
+		stats := result.Statistics
+		fmt.Printf("%s\n%s\n%s\n", name, line1, line2)
+		fmt.Printf("  %d states, %d iterations (%s)\n", stats.Samples, result.Iterations, convergence)
+		fmt.Printf("  RMS position %.3f km (radial %.3f, in-track %.3f, cross-track %.3f), max %.3f km\n",
+			stats.RMSPosition, stats.RMSRadial, stats.RMSInTrack, stats.RMSCrossTrack, stats.MaxPosition)
+		fmt.Printf("  RMS velocity %.3f m/s\n\n", stats.RMSVelocity*1000)
+
+		if out != nil {
+			if _, err := fmt.Fprintf(out, "%s\n%s\n%s\n", name, line1, line2); err != nil {
+				return fmt.Errorf("failed to write TLE: %w", err)
+			}
+		}
+	}
+
+	if path, ok := flagValues["--out"]; ok {
+		fmt.Printf("TLEs written to %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"starlink/pkg/footprint"
+	"starlink/pkg/kml"
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// runFootprint computes the coverage footprint of each satellite at the evaluation
+// time and writes them as GeoJSON to the file given by --out, or to standard output.
+// When kmlPath is set, the satellite positions and footprints are also written there
+// as KML.
+func runFootprint(satellites []model.Satellite, flagValues map[string]string, evaluationTime time.Time,
+	displayLocation *time.Location, kmlPath string) error {
+	opts, err := parseFootprintFlags(flagValues)
+	if err != nil {
+		return err
+	}
+
+	var footprints []footprint.Footprint
+	byName := make(map[string]footprint.Footprint)
+	locations := make(map[string]*model.SatLocation)
+	var names []string
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sat.Name, err)
+			continue
+		}
+
+		fp, err := footprint.Compute(sat.Name, p, evaluationTime, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sat.Name, err)
+			continue
+		}
+		footprints = append(footprints, fp)
+		byName[sat.Name] = fp
+		locations[sat.Name] = orbital.CalculateSatelliteLocation(sat.Elements, evaluationTime)
+		names = append(names, sat.Name)
+	}
+
+	var out io.Writer = os.Stdout
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := footprint.WriteGeoJSON(out, footprints); err != nil {
+		return err
+	}
+
+	if kmlPath != "" {
+		content := kml.GenerateKMLWithFootprints(names, locations, byName, evaluationTime.In(displayLocation))
+		if err := os.WriteFile(kmlPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write KML file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "KML file created with %d footprints: %s\n", len(footprints), kmlPath)
+	}
+
+	return nil
+}
+
+// parseFootprintFlags builds the footprint options from command line values. The
+// minimum elevation defaults to that of Starlink user terminals.
+func parseFootprintFlags(flagValues map[string]string) (footprint.Options, error) {
+	opts := footprint.Options{MinElevation: footprint.DefaultMinElevation}
+	var err error
+
+	if value, ok := flagValues["--min-elevation"]; ok {
+		if opts.MinElevation, err = strconv.ParseFloat(value, 64); err != nil {
+			return opts, fmt.Errorf("invalid --min-elevation: %w", err)
+		}
+	}
+	if value, ok := flagValues["--points"]; ok {
+		if opts.Points, err = strconv.Atoi(value); err != nil || opts.Points < 3 {
+			return opts, fmt.Errorf("invalid --points %q", value)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"starlink/pkg/groundtrack"
+	"starlink/pkg/kml"
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// runGroundTrack generates the ground track of each satellite with its equator
+// crossings and writes it as CSV to the file given by --out, or to standard output.
+// When kmlPath is set, the tracks are also written there as KML line strings.
+func runGroundTrack(satellites []model.Satellite, flagValues map[string]string, evaluationTime time.Time,
+	kmlPath string) error {
+	opts, err := parseGroundTrackFlags(flagValues, evaluationTime)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := groundtrack.NewCSVWriter(out)
+	var tracks []groundtrack.Track
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sat.Name, err)
+			continue
+		}
+
+		track, err := groundtrack.Generate(sat.Name, p, opts)
+		if err != nil {
+			return err
+		}
+		if err := writer.Write(track); err != nil {
+			return err
+		}
+		tracks = append(tracks, track)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if kmlPath != "" {
+		content := kml.GenerateGroundTrackKML(tracks, opts.Start)
+		if err := os.WriteFile(kmlPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write KML file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "KML file created with %d ground tracks: %s\n", len(tracks), kmlPath)
+	}
+
+	return nil
+}
+
+// parseGroundTrackFlags builds the ground track options from command line values.
+// Without --stop the window is --orbits orbital periods (default 1) from the start.
+func parseGroundTrackFlags(flagValues map[string]string, evaluationTime time.Time) (groundtrack.Options, error) {
+	opts := groundtrack.Options{Nodes: true}
+
+	start, stop, err := parseWindowFlags(flagValues, evaluationTime, 0)
+	if err != nil {
+		return opts, err
+	}
+	opts.Start, opts.Stop = start, stop
+
+	if _, ok := flagValues["--stop"]; !ok {
+		opts.Orbits = 1
+		if value, ok := flagValues["--orbits"]; ok {
+			if opts.Orbits, err = strconv.ParseFloat(value, 64); err != nil || opts.Orbits <= 0 {
+				return opts, fmt.Errorf("invalid --orbits %q", value)
+			}
+		}
+	}
+	if value, ok := flagValues["--step"]; ok {
+		if opts.Step, err = time.ParseDuration(value); err != nil {
+			return opts, fmt.Errorf("invalid --step: %w", err)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/iod"
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/tle"
+	"starlink/pkg/util"
+)
+
+// defaultObservationElevation is the elevation above which --observe simulates
+// observations [degree]
+const defaultObservationElevation = 15.0
+
+// runObserve simulates topocentric observations of the satellites from the observer,
+// sampled every --step (30 s by default) while they are above --min-elevation and the
+// horizon mask, and writes them as CSV to --out or standard output. The window defaults
+// to 24 hours from the evaluation time. --measure range adds slant ranges and --noise
+// adds Gaussian noise to the angles [arcsec] and ranges [km].
+func runObserve(satellites []model.Satellite, station *observer.Station, flagValues map[string]string,
+	evaluationTime time.Time) error {
+	if station == nil {
+		return errors.New("--observe requires --observer")
+	}
+	start, stop, err := parseWindowFlags(flagValues, evaluationTime, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	step := 30 * time.Second
+	if value, ok := flagValues["--step"]; ok {
+		if step, err = time.ParseDuration(value); err != nil || step <= 0 {
+			return fmt.Errorf("invalid --step %q", value)
+		}
+	}
+	minElevation := defaultObservationElevation
+	if value, ok := flagValues["--min-elevation"]; ok {
+		if minElevation, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid --min-elevation: %w", err)
+		}
+	}
+	ranged, err := parseMeasureFlag(flagValues)
+	if err != nil {
+		return err
+	}
+	angleNoise, rangeNoise, err := parseNoiseFlag(flagValues, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	// Seeded so that repeated runs produce the same observations
+	rng := rand.New(rand.NewPCG(1, 2))
+	var observations []iod.Observation
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sat.Name, err)
+		}
+		var times []time.Time
+		for t := start; !t.After(stop); t = t.Add(step) {
+			look := station.Look(p, t)
+			if look.Visible && look.Elevation >= minElevation {
+				times = append(times, t)
+			}
+		}
+		simulated := iod.Simulate(sat.Name, p, station, times, ranged)
+		iod.AddNoise(simulated, angleNoise, rangeNoise, rng)
+		observations = append(observations, simulated...)
+	}
+
+	var out io.Writer = os.Stdout
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+		fmt.Printf("Writing %d observations to %s\n", len(observations), path)
+	}
+	return iod.WriteCSV(out, observations)
+}
+
+// runDetermineOrbit determines an element set for each object of the observations
+// file given by --observations, made from the observer: an initial orbit from three
+// observations of the first track, refined by least squares over all of them. --noise
+// sets the measurement noise that weights the residuals, --fit-drag also solves for
+// the mean motion derivative and --epoch sets the epoch (an offset from the initial
+// orbit's time). Objects found in the TLE data lend their catalog identification and
+// drag terms, and their catalog positions are compared with the solution.
+func runDetermineOrbit(tleData string, station *observer.Station, flagValues map[string]string,
+	displayLocation *time.Location) error {
+	path, ok := flagValues["--observations"]
+	if !ok {
+		return errors.New("--determine-orbit requires --observations FILE")
+	}
+	if station == nil {
+		return errors.New("--determine-orbit requires --observer")
+	}
+	observations, err := iod.LoadCSV(path, station)
+	if err != nil {
+		return err
+	}
+	var opts iod.RefineOptions
+	if opts.AngleNoise, opts.RangeNoise, err = parseNoiseFlag(flagValues, iod.DefaultAngleNoise, iod.DefaultRangeNoise); err != nil {
+		return err
+	}
+	opts.Fit.FitDrag = flagValues["--fit-drag"] != ""
+
+	// Observations of each object, in the order the objects first appear
+	var names []string
+	byName := make(map[string][]iod.Observation)
+	for _, o := range observations {
+		if _, ok := byName[o.Satellite]; !ok {
+			names = append(names, o.Satellite)
+		}
+		byName[o.Satellite] = append(byName[o.Satellite], o)
+	}
+
+	for _, name := range names {
+		observations := byName[name]
+		initial, method, err := iod.Determine(observations)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", name, err)
+			continue
+		}
+		el := orbital.StateToKeplerian(initial)
+		fmt.Printf("--- %s: %d observations ---\n", name, len(observations))
+		fmt.Printf("  Initial orbit (%s) at %s: a %.3f km, e %.6f, i %.4f°, RAAN %.4f°\n", method,
+			initial.Time.In(displayLocation).Format(time.RFC3339), el.SemiMajorAxis, el.Eccentricity,
+			el.Inclination, el.Raan)
+
+		fitOpts := opts
+		if value, ok := flagValues["--epoch"]; ok {
+			if fitOpts.Fit.Epoch, err = util.ParseTime(value, initial.Time); err != nil {
+				return fmt.Errorf("invalid --epoch: %w", err)
+			}
+		}
+		var template *model.TleOrbitalElement
+		if sats, err := tle.FindSatellites(tleData, []string{name}); err == nil && len(sats) > 0 {
+			template = sats[0].Elements
+		}
+
+		solution, err := iod.Refine(observations, initial, template, fitOpts)
+		if err != nil {
+			fmt.Printf("  Refinement failed: %v\n\n", err)
+			continue
+		}
+		printSolution(name, solution, template, observations)
+	}
+	return nil
+}
+
+// printSolution prints a refined element set as a TLE with its residuals and, for
+// objects in the catalog, its distance from the catalog position at the last observation
+func printSolution(name string, solution iod.Solution, template *model.TleOrbitalElement, observations []iod.Observation) {
+	convergence := "converged"
+	if !solution.Converged {
+		convergence = "not converged"
+	}
+	r := solution.Residuals
+	line1, line2 := tle.FormatTle(solution.Elements, 0)
+	fmt.Printf("  Refined in %d iterations (%s)\n", solution.Iterations, convergence)
+	fmt.Printf("  RMS residuals: RA %.2f\", Dec %.2f\"", r.RMSAscension, r.RMSDeclination)
+	if r.Ranged > 0 {
+		fmt.Printf(", range %.3f km (%d ranged)", r.RMSRange, r.Ranged)
+	}
+	fmt.Printf("\n%s\n%s\n%s\n", name, line1, line2)
+
+	if template != nil {
+		last := observations[0].Time
+		for _, o := range observations {
+			if o.Time.After(last) {
+				last = o.Time
+			}
+		}
+		catalog, errCatalog := orbital.NewPropagator(template)
+		solved, errSolved := orbital.NewPropagator(solution.Elements)
+		if errCatalog == nil && errSolved == nil {
+			fmt.Printf("  Catalog position difference at the last observation: %.3f km\n",
+				solved.PositionECI(last).Sub(catalog.PositionECI(last)).Norm())
+		}
+	}
+	fmt.Println()
+}
+
+// parseMeasureFlag reports whether --measure asks for ranges in addition to angles
+func parseMeasureFlag(flagValues map[string]string) (bool, error) {
+	switch value := flagValues["--measure"]; value {
+	case "", "angles":
+		return false, nil
+	case "range", "angles+range":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid --measure %q (expected angles or range)", value)
+	}
+}
+
+// parseNoiseFlag parses --noise as the angle noise in arcsec, optionally followed by
+// the range noise in km, falling back to the given defaults
+func parseNoiseFlag(flagValues map[string]string, angleNoise, rangeNoise float64) (float64, float64, error) {
+	value, ok := flagValues["--noise"]
+	if !ok {
+		return angleNoise, rangeNoise, nil
+	}
+	fields := strings.Split(value, ",")
+	if len(fields) > 2 {
+		return 0, 0, fmt.Errorf("invalid --noise %q: expected arcsec[,km]", value)
+	}
+	var err error
+	if angleNoise, err = strconv.ParseFloat(strings.TrimSpace(fields[0]), 64); err != nil || angleNoise < 0 {
+		return 0, 0, fmt.Errorf("invalid --noise %q", value)
+	}
+	if len(fields) == 2 {
+		if rangeNoise, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64); err != nil || rangeNoise < 0 {
+			return 0, 0, fmt.Errorf("invalid --noise %q", value)
+		}
+	}
+	return angleNoise, rangeNoise, nil
+}
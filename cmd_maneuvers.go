@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"starlink/pkg/maneuver"
+	"starlink/pkg/tle"
+)
+
+// runManeuvers detects maneuvers in the TLE history given by --history and prints a
+// maneuver log per object. Objects are identified by catalog number; those without a
+// name line in the history are named from the TLE data when they are found there. The
+// maneuvers of all objects are also written as CSV to --out.
+func runManeuvers(tleData string, flagValues map[string]string, displayLocation *time.Location) error {
+	path, ok := flagValues["--history"]
+	if !ok {
+		return errors.New("--maneuvers requires --history FILE")
+	}
+	sets, err := tle.LoadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	// Catalog names by catalog number for histories without name lines
+	names := make(map[string]string)
+	for _, sat := range tle.ParseCatalog(tleData) {
+		names[sat.Elements.CatalogNumber] = sat.Name
+	}
+
+	var logs []maneuver.Log
+	for _, h := range maneuver.Histories(sets) {
+		if name, ok := names[h.CatalogNumber]; ok && h.Name == h.CatalogNumber {
+			h.Name = name
+		}
+		log := maneuver.Detect(h, maneuver.Options{})
+		logs = append(logs, log)
+		printManeuverLog(log, displayLocation)
+	}
+
+	if path, ok := flagValues["--out"]; ok {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		if err := maneuver.WriteCSV(file, logs); err != nil {
+			return fmt.Errorf("failed to write maneuver log: %w", err)
+		}
+		fmt.Printf("Maneuver log written to %s\n", path)
+	}
+	return nil
+}
+
+// printManeuverLog prints the maneuvers detected in the history of one object
+func printManeuverLog(log maneuver.Log, displayLocation *time.Location) {
+	fmt.Printf("--- %s (%s): %d element sets", log.Name, log.CatalogNumber, log.ElementSets)
+	if log.ElementSets > 0 {
+		fmt.Printf(" from %s to %s", log.First.In(displayLocation).Format(time.RFC3339),
+			log.Last.In(displayLocation).Format(time.RFC3339))
+	}
+	fmt.Println(" ---")
+	if log.ElementSets < 2 {
+		fmt.Printf("  Not enough element sets\n\n")
+		return
+	}
+	fmt.Printf("  Thresholds: a %.3f km, i %.4f°, RAAN %.4f°\n", log.Thresholds.SemiMajorAxis,
+		log.Thresholds.Inclination, log.Thresholds.Raan)
+	if len(log.Maneuvers) == 0 {
+		fmt.Printf("  No maneuvers detected\n\n")
+		return
+	}
+
+	fmt.Printf("  %-25s %-13s %10s %9s %9s %9s %9s %9s\n", "Epoch (est.)", "Kind", "da [km]", "di [°]",
+		"dRAAN [°]", "dVt [m/s]", "dVn [m/s]", "dV [m/s]")
+	for _, m := range log.Maneuvers {
+		fmt.Printf("  %-25s %-13s %10.3f %9.4f %9.4f %9.3f %9.3f %9.3f\n",
+			m.Epoch.In(displayLocation).Format(time.RFC3339), m.Kind, m.DeltaSemiMajorAxis,
+			m.DeltaInclination, m.DeltaRaan, m.DeltaVInTrack, m.DeltaVCrossTrack, m.DeltaV)
+	}
+	fmt.Printf("  %d maneuvers, total delta-V %.3f m/s\n\n", len(log.Maneuvers), log.TotalDeltaV)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"starlink/pkg/brightness"
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/passes"
+)
+
+// passTimeFormat shows pass event times to the millisecond
+const passTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// runPasses predicts passes of each satellite over the station and prints them.
+// The window defaults to 24 hours from the evaluation time. The minimum elevation
+// defaults to 10 degrees, or to 0 when the observer has a horizon mask.
+func runPasses(satellites []model.Satellite, station *observer.Station, magnitudes *brightness.Magnitudes,
+	flagValues map[string]string, evaluationTime time.Time, displayLocation *time.Location) error {
+	if station == nil {
+		return errors.New("--passes requires --observer lat,lon,alt")
+	}
+
+	opts, err := parsePassFlags(flagValues, evaluationTime, station.Observer.Mask != nil)
+	if err != nil {
+		return err
+	}
+
+	obs := station.Observer
+	fmt.Printf("Passes over %.6f,%.6f,%.3f from %s to %s (min elevation %.1f°)\n",
+		obs.Lat, obs.Lng, obs.Alt,
+		opts.Start.In(displayLocation).Format(time.RFC3339),
+		opts.Stop.In(displayLocation).Format(time.RFC3339), opts.MinElevation)
+	if opts.Optical {
+		fmt.Printf("Optical visibility after %s twilight (Sun below %.0f°)\n", opts.Twilight, opts.Twilight.SunAltitude())
+	}
+	if obs.Mask != nil {
+		fmt.Printf("Horizon mask with %d points applied\n", len(obs.Mask.Azimuths))
+	}
+	fmt.Println()
+
+	total := 0
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", sat.Name, err)
+			continue
+		}
+
+		opts.StandardMagnitude = magnitudes.For(sat.Name)
+		found, err := passes.FindPasses(sat.Name, p, station, opts)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		fmt.Printf("--- %s: %d pass(es) ---\n", sat.Name, len(found))
+		for _, pass := range found {
+			printPass(pass, opts.Optical, displayLocation)
+		}
+		total += len(found)
+	}
+
+	fmt.Printf("Found %d passes.\n", total)
+	return nil
+}
+
+// printPass prints the events of a single pass, followed by its optical visibility
+// and visible segments with apparent magnitudes when optical is set
+func printPass(pass passes.Pass, optical bool, displayLocation *time.Location) {
+	aosNote, losNote := "", ""
+	if pass.StartTruncated {
+		aosNote = " (window start)"
+	}
+	if pass.EndTruncated {
+		losNote = " (window stop)"
+	}
+
+	fmt.Printf("  AOS %s  Az %7.3f°  El %6.3f°%s%s\n",
+		pass.AOS.Time.In(displayLocation).Format(passTimeFormat), pass.AOS.Azimuth, pass.AOS.Elevation,
+		magnitudeColumn(pass.AOS, optical), aosNote)
+	fmt.Printf("  TCA %s  Az %7.3f°  El %6.3f°%s\n",
+		pass.TCA.Time.In(displayLocation).Format(passTimeFormat), pass.TCA.Azimuth, pass.TCA.Elevation,
+		magnitudeColumn(pass.TCA, optical))
+	fmt.Printf("  LOS %s  Az %7.3f°  El %6.3f°%s%s\n",
+		pass.LOS.Time.In(displayLocation).Format(passTimeFormat), pass.LOS.Azimuth, pass.LOS.Elevation,
+		magnitudeColumn(pass.LOS, optical), losNote)
+	fmt.Printf("  Duration %s\n", pass.Duration().Round(time.Millisecond))
+	if optical {
+		fmt.Printf("  Optical  %s\n", pass.Visibility)
+		for _, segment := range pass.Visible {
+			fmt.Printf("  Visible  %s - %s  El %6.3f° - %6.3f°  (%s)\n",
+				segment.Start.Time.In(displayLocation).Format(passTimeFormat),
+				segment.Stop.Time.In(displayLocation).Format(passTimeFormat),
+				segment.Start.Elevation, segment.Stop.Elevation, segment.Duration().Round(time.Millisecond))
+			fmt.Printf("  Brightest %s  Az %7.3f°  El %6.3f°%s\n",
+				segment.Brightest.Time.In(displayLocation).Format(passTimeFormat),
+				segment.Brightest.Azimuth, segment.Brightest.Elevation, magnitudeColumn(segment.Brightest, true))
+		}
+	}
+	fmt.Println()
+}
+
+// magnitudeColumn formats the apparent magnitude of an event, or nothing when
+// optical visibility was not evaluated
+func magnitudeColumn(event passes.Event, optical bool) string {
+	if !optical {
+		return ""
+	}
+	if math.IsNaN(event.Magnitude) {
+		return "  Mag    -"
+	}
+	return fmt.Sprintf("  Mag %4.1f", event.Magnitude)
+}
+
+// parsePassFlags builds the pass search options from command line values
+func parsePassFlags(flagValues map[string]string, evaluationTime time.Time, hasMask bool) (passes.Options, error) {
+	opts := passes.Options{MinElevation: 10.0}
+	if hasMask {
+		opts.MinElevation = 0
+	}
+
+	start, stop, err := parseWindowFlags(flagValues, evaluationTime, 24*time.Hour)
+	if err != nil {
+		return opts, err
+	}
+	opts.Start, opts.Stop = start, stop
+
+	if value, ok := flagValues["--min-elevation"]; ok {
+		if opts.MinElevation, err = strconv.ParseFloat(value, 64); err != nil {
+			return opts, fmt.Errorf("invalid --min-elevation: %w", err)
+		}
+	}
+	if value, ok := flagValues["--step"]; ok {
+		if opts.Step, err = time.ParseDuration(value); err != nil {
+			return opts, fmt.Errorf("invalid --step: %w", err)
+		}
+	}
+	if value, ok := flagValues["--twilight"]; ok {
+		if opts.Twilight, err = observer.ParseTwilight(value); err != nil {
+			return opts, fmt.Errorf("invalid --twilight: %w", err)
+		}
+		opts.Optical = true
+	}
+
+	return opts, nil
+}
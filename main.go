@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 
+	"starlink/pkg/batch"
+	"starlink/pkg/brightness"
 	"starlink/pkg/kml"
 	"starlink/pkg/model"
+	"starlink/pkg/observer"
 	"starlink/pkg/orbital"
 	"starlink/pkg/tle"
 	"starlink/pkg/util"
@@ -28,11 +34,18 @@ func main() {
 	kmlFilePath := "starlink_satellites.kml"
 	processAllSatellites := false
 
+	// Command that replaces the default position output, e.g. "ephemeris"
+	command := ""
+
+	// Values of flags that take an argument, keyed by flag name
+	flagValues := make(map[string]string)
+
 	// Simple arg parsing
 	i := 0
 	for i < len(satellites) {
+		switch satellites[i] {
 		// Check for KML output flag
-		if satellites[i] == "--kml" {
+		case "--kml":
 			outputKML = true
 			satellites = append(satellites[:i], satellites[i+1:]...)
 
@@ -42,13 +55,45 @@ func main() {
 				satellites = append(satellites[:i], satellites[i+1:]...)
 			}
 			continue // Don't increment i since we removed an element
-		}
 
 		// Check for all satellites flag
-		if satellites[i] == "--all" {
+		case "--all":
 			processAllSatellites = true
 			satellites = append(satellites[:i], satellites[i+1:]...)
 			continue // Don't increment i since we removed an element
+
+		// Check for commands
+		case "--ephemeris", "--passes", "--eclipses", "--doppler", "--groundtrack", "--footprint",
+			"--conjunctions", "--compare-cdm", "--elements",
+			"--fit-tle", "--observe", "--determine-orbit",
+			"--maneuvers", "--decay", "--constellation":
+			command = strings.TrimPrefix(satellites[i], "--")
+			satellites = append(satellites[:i], satellites[i+1:]...)
+			continue // Don't increment i since we removed an element
+
+		// Check for the drag fit switch of --fit-tle and --determine-orbit
+		case "--fit-drag":
+			flagValues["--fit-drag"] = "true"
+			satellites = append(satellites[:i], satellites[i+1:]...)
+			continue // Don't increment i since we removed an element
+
+		// Flags that require a value
+		case "--time", "--tz", "--start", "--stop", "--step", "--count", "--frame", "--out", "--workers",
+			"--observer", "--horizon", "--min-elevation", "--shadow-model",
+			"--twilight", "--magnitude", "--downlink", "--uplink", "--orbits",
+			"--points", "--threshold", "--against", "--covariance", "--hbr",
+			"--pc-warning", "--pc-alert", "--cdm-out", "--cdm-format", "--cdm",
+			"--propagator", "--forces", "--gravity-field", "--atmosphere", "--area-to-mass",
+			"--states", "--epoch", "--observations", "--measure", "--noise", "--history",
+			"--flux", "--decay-method", "--within", "--uncertainty":
+			name := satellites[i]
+			if i+1 >= len(satellites) {
+				fmt.Printf("Missing value for %s\n", name)
+				os.Exit(2)
+			}
+			flagValues[name] = satellites[i+1]
+			satellites = append(satellites[:i], satellites[i+2:]...)
+			continue // Don't increment i since we removed elements
 		}
 
 		i++ // Move to next argument
@@ -68,49 +113,142 @@ func main() {
 		panic("Error fetching TLE data")
 	}
 
+	// Resolve the evaluation instant and display timezone
+	evaluationTime, displayLocation, err := parseTimeFlags(flagValues, time.Now())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Optional ground observer for look angles
+	var station *observer.Station
+	if value, ok := flagValues["--observer"]; ok {
+		obs, err := observer.ParseObserver(value)
+		if err == nil {
+			if path, ok := flagValues["--horizon"]; ok {
+				obs.Mask, err = observer.LoadHorizonMask(path)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+		station = observer.NewStation(obs)
+	} else if _, ok := flagValues["--horizon"]; ok {
+		fmt.Println("Error: --horizon requires --observer")
+		os.Exit(2)
+	}
+
+	// Optional standard magnitudes overriding the Starlink generation defaults
+	var magnitudes *brightness.Magnitudes
+	if value, ok := flagValues["--magnitude"]; ok {
+		magnitudes, err = brightness.ParseMagnitudes(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	// Commands that operate on the selected satellites instead of printing positions
+	if command != "" {
+		var targets []model.Satellite
+		if processAllSatellites {
+			targets = tle.ParseCatalog(tleData)
+		} else {
+			targets, err = tle.FindSatellites(tleData, satellites)
+		}
+		// Commands that can also write KML receive the path only when --kml is given
+		kmlPath := ""
+		if outputKML {
+			kmlPath = kmlFilePath
+		}
+		if err == nil {
+			switch command {
+			case "ephemeris":
+				err = runEphemeris(targets, flagValues, evaluationTime)
+			case "passes":
+				err = runPasses(targets, station, magnitudes, flagValues, evaluationTime, displayLocation)
+			case "doppler":
+				err = runDoppler(targets, station, flagValues, evaluationTime)
+			case "groundtrack":
+				err = runGroundTrack(targets, flagValues, evaluationTime, kmlPath)
+			case "conjunctions":
+				err = runConjunctions(targets, tleData, processAllSatellites, flagValues, evaluationTime, displayLocation)
+			case "compare-cdm":
+				err = runCompareCDM(tleData, flagValues, displayLocation)
+			case "footprint":
+				err = runFootprint(targets, flagValues, evaluationTime, displayLocation, kmlPath)
+			case "eclipses":
+				err = runEclipses(targets, flagValues, evaluationTime, displayLocation)
+			case "elements":
+				err = runElements(targets, evaluationTime, displayLocation)
+			case "fit-tle":
+				err = runFitTle(tleData, flagValues)
+			case "observe":
+				err = runObserve(targets, station, flagValues, evaluationTime)
+			case "determine-orbit":
+				err = runDetermineOrbit(tleData, station, flagValues, displayLocation)
+			case "maneuvers":
+				err = runManeuvers(tleData, flagValues, displayLocation)
+			case "decay":
+				err = runDecay(targets, processAllSatellites, flagValues, evaluationTime, displayLocation)
+			case "constellation":
+				err = runConstellation(targets, flagValues, evaluationTime, displayLocation)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error running --%s: %v\n", command, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Store satellite locations if needed for KML
 	locations := make(map[string]*model.SatLocation)
-	currentTime := time.Now()
 
-	// If --all flag is set, get all satellites from TLE data
+	processedCount := 0
+
+	// If --all flag is set, propagate every satellite in the TLE data concurrently
 	if processAllSatellites {
 		fmt.Println("Processing all satellites from TLE data...")
-		// Get all satellite names
-		allSatellites := tle.GetAllSatellites(tleData)
-		if len(allSatellites) == 0 {
+		catalog := tle.ParseCatalog(tleData)
+		if len(catalog) == 0 {
 			fmt.Println("No satellites found in TLE data.")
 			return
 		}
 
-		fmt.Printf("Found %d satellites in TLE data.\n", len(allSatellites))
+		fmt.Printf("Found %d satellites in TLE data.\n", len(catalog))
 
 		// If there are also specific satellites requested, merge them
 		if len(satellites) > 0 {
 			// Create a set for faster lookup
 			satelliteSet := make(map[string]bool)
-			for _, sat := range allSatellites {
-				satelliteSet[sat] = true
+			for _, sat := range catalog {
+				satelliteSet[sat.Name] = true
 			}
 
 			// Add any requested satellites that aren't already in the list
 			for _, sat := range satellites {
 				if _, exists := satelliteSet[sat]; !exists {
-					allSatellites = append(allSatellites, sat)
+					extra, err := tle.FindSatellites(tleData, []string{sat})
+					if err != nil {
+						fmt.Printf("Error finding satellite %v\n", err)
+						continue
+					}
+					catalog = append(catalog, extra...)
 				}
 			}
 		}
 
-		// Replace the satellites slice with all satellites
-		satellites = allSatellites
-	}
-
-	// Process each requested satellite
-	processedCount := 0
-	for _, satelliteName := range satellites {
-		location := processSatellite(satelliteName, tleData, nil)
-		if location != nil {
-			locations[satelliteName] = location
-			processedCount++
+		processedCount = processCatalog(catalog, flagValues, evaluationTime, station, magnitudes, locations)
+	} else {
+		// Process each requested satellite
+		for _, satelliteName := range satellites {
+			location := processSatellite(satelliteName, tleData, nil, evaluationTime, displayLocation, station, magnitudes)
+			if location != nil {
+				locations[satelliteName] = location
+				processedCount++
+			}
 		}
 	}
 
@@ -126,7 +264,7 @@ func main() {
 			validSatellites = append(validSatellites, satName)
 		}
 
-		kmlContent := kml.GenerateKML(validSatellites, locations, currentTime)
+		kmlContent := kml.GenerateKML(validSatellites, locations, evaluationTime.In(displayLocation))
 
 		err := os.WriteFile(kmlFilePath, []byte(kmlContent), 0644)
 		if err != nil {
@@ -139,8 +277,12 @@ func main() {
 }
 
 // processSatellite processes a single satellite, calculating and displaying its position
+// at evaluationTime, with times shown in displayLocation, and its look angles and
+// apparent magnitude (using the standard magnitudes) from station when one is given
 // Returns the location for KML generation if successful
-func processSatellite(satelliteName, tleData string, defaultElements *model.TleOrbitalElement) *model.SatLocation {
+func processSatellite(satelliteName, tleData string, defaultElements *model.TleOrbitalElement,
+	evaluationTime time.Time, displayLocation *time.Location, station *observer.Station,
+	magnitudes *brightness.Magnitudes) *model.SatLocation {
 	fmt.Printf("\n--- Processing satellite: %s ---\n", satelliteName)
 
 	var satelliteElements *model.TleOrbitalElement
@@ -160,23 +302,154 @@ func processSatellite(satelliteName, tleData string, defaultElements *model.TleO
 		satelliteElements = defaultElements
 	}
 
-	// Calculate satellite position at current time
-	currentTime := time.Now()
-	satLocation1 := orbital.CalculateSatelliteLocation(satelliteElements, currentTime)
-
-	// Calculate velocity
-	satLocation2 := orbital.CalculateSatelliteLocation(satelliteElements, currentTime.Add(time.Second))
-	velocity := orbital.CalculateVelocity(satLocation1, satLocation2)
-
-	// Store velocity in the location object for KML generation
-	satLocation1.Velocity = velocity
+	// Calculate satellite position and Earth-fixed velocity at the evaluation time, as
+	// the --all path does through the propagator
+	satLocation1 := orbital.CalculateSatelliteLocation(satelliteElements, evaluationTime)
+	velocity := satLocation1.Velocity
 
 	// Display results in a more structured format
-	fmt.Printf("\nResults for %s at %s:\n", satelliteName, currentTime.Format(time.RFC3339))
+	fmt.Printf("\nResults for %s at %s:\n", satelliteName, evaluationTime.In(displayLocation).Format(time.RFC3339))
 	fmt.Printf("  Latitude:  %.6f°\n", satLocation1.Lat)
 	fmt.Printf("  Longitude: %.6f°\n", satLocation1.Lng)
 	fmt.Printf("  Altitude:  %.3f km\n", satLocation1.Alt)
 	fmt.Printf("  Velocity:  %.3f km/s\n", velocity)
+	fmt.Printf("  In shadow: %t\n", satLocation1.InShadow)
+	if station != nil {
+		if p, err := orbital.NewPropagator(satelliteElements); err == nil {
+			look := station.Look(p, evaluationTime)
+			fmt.Printf("  Azimuth:   %.3f°\n", look.Azimuth)
+			fmt.Printf("  Elevation: %.3f°\n", look.Elevation)
+			fmt.Printf("  Range:     %.3f km\n", look.Range)
+			fmt.Printf("  Range rate: %.3f km/s\n", look.RangeRate)
+			fmt.Printf("  Visible:   %t\n", look.Visible)
+			if magnitude, lit := brightness.Estimate(p, station, evaluationTime, magnitudes.For(satelliteName)); lit {
+				fmt.Printf("  Magnitude: %.1f\n", magnitude)
+			} else {
+				fmt.Printf("  Magnitude: - (in umbra)\n")
+			}
+		}
+	}
+	for _, warning := range satLocation1.Warnings {
+		fmt.Printf("  Warning:   %s\n", warning)
+	}
 
 	return satLocation1
 }
+
+// parseTimeFlags resolves the evaluation instant from --time (defaulting to now)
+// and the display timezone from --tz (defaulting to the local zone)
+func parseTimeFlags(flagValues map[string]string, now time.Time) (time.Time, *time.Location, error) {
+	evaluationTime := now
+	displayLocation := time.Local
+
+	if value, ok := flagValues["--time"]; ok {
+		t, err := util.ParseTime(value, now)
+		if err != nil {
+			return now, displayLocation, fmt.Errorf("invalid --time: %w", err)
+		}
+		evaluationTime = t
+	}
+	if value, ok := flagValues["--tz"]; ok {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			return now, displayLocation, fmt.Errorf("invalid --tz: %w", err)
+		}
+		displayLocation = location
+	}
+
+	return evaluationTime, displayLocation, nil
+}
+
+// processCatalog propagates a whole catalog with the batch engine, printing one line
+// per satellite (with look angles and magnitude when station is given) and storing successful
+// locations for KML generation.
+// Returns the number of satellites processed successfully.
+func processCatalog(catalog []model.Satellite, flagValues map[string]string,
+	evaluationTime time.Time, station *observer.Station, magnitudes *brightness.Magnitudes,
+	locations map[string]*model.SatLocation) int {
+	workers := 0
+	if value, ok := flagValues["--workers"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			fmt.Printf("Invalid --workers %q, using one worker per CPU\n", value)
+		} else {
+			workers = n
+		}
+	}
+
+	// Stop scheduling work on Ctrl-C but still report what was computed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := batch.Propagate(ctx, catalog, []time.Time{evaluationTime}, workers)
+	if err != nil {
+		fmt.Printf("Batch interrupted: %v\n", err)
+	}
+
+	fmt.Printf("\n%-24s %12s %12s %10s %10s %6s", "Satellite", "Lat [deg]", "Lng [deg]", "Alt [km]", "V [km/s]", "Shadow")
+	if station != nil {
+		fmt.Printf(" %9s %9s %10s %5s", "Az [deg]", "El [deg]", "Range [km]", "Mag")
+	}
+	fmt.Println()
+
+	processedCount := 0
+	for i, result := range results {
+		if result.Err != nil {
+			fmt.Printf("%-24s error: %v\n", result.Satellite, result.Err)
+			continue
+		}
+
+		location := result.Locations[0]
+		shadow := "no"
+		if location.InShadow {
+			shadow = "yes"
+		}
+		fmt.Printf("%-24s %12.6f %12.6f %10.3f %10.3f %6s", result.Satellite,
+			location.Lat, location.Lng, location.Alt, location.Velocity, shadow)
+		if station != nil {
+			if p, err := orbital.NewPropagator(catalog[i].Elements); err == nil {
+				look := station.Look(p, evaluationTime)
+				fmt.Printf(" %9.3f %9.3f %10.3f", look.Azimuth, look.Elevation, look.Range)
+				if magnitude, lit := brightness.Estimate(p, station, evaluationTime, magnitudes.For(result.Satellite)); lit {
+					fmt.Printf(" %5.1f", magnitude)
+				} else {
+					fmt.Printf(" %5s", "-")
+				}
+			}
+		}
+		if len(location.Warnings) > 0 {
+			fmt.Printf("  (%d warning(s))", len(location.Warnings))
+		}
+		fmt.Println()
+
+		locations[result.Satellite] = location
+		processedCount++
+	}
+
+	return processedCount
+}
+
+// parseWindowFlags resolves a time window from --start (defaulting to the evaluation
+// time) and --stop (defaulting to start + span). Offsets in --start are relative to
+// the evaluation time and offsets in --stop are relative to the start.
+func parseWindowFlags(flagValues map[string]string, evaluationTime time.Time, span time.Duration) (time.Time, time.Time, error) {
+	start := evaluationTime
+	if value, ok := flagValues["--start"]; ok {
+		t, err := util.ParseTime(value, evaluationTime)
+		if err != nil {
+			return start, start, fmt.Errorf("invalid --start: %w", err)
+		}
+		start = t
+	}
+
+	stop := start.Add(span)
+	if value, ok := flagValues["--stop"]; ok {
+		t, err := util.ParseTime(value, start)
+		if err != nil {
+			return start, stop, fmt.Errorf("invalid --stop: %w", err)
+		}
+		stop = t
+	}
+
+	return start, stop, nil
+}
@@ -0,0 +1,112 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// Result holds the propagated positions of one catalog satellite
+type Result struct {
+	Satellite string               // Satellite name
+	Locations []*model.SatLocation // One location per requested time, in the same order
+	Err       error                // Non-nil when the satellite could not be propagated
+}
+
+// Propagate computes the location of every catalog satellite at every requested time.
+// Work is spread over at most workers goroutines (runtime.NumCPU() when workers <= 0).
+// Results are returned in catalog order; a satellite that fails carries its own error
+// and does not stop the rest of the batch. If ctx is cancelled, Propagate stops
+// scheduling work and returns ctx.Err() together with the results computed so far.
+func Propagate(ctx context.Context, catalog []model.Satellite, times []time.Time, workers int) ([]Result, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(catalog) {
+		workers = len(catalog)
+	}
+
+	results := make([]Result, len(catalog))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				// Each worker writes only its own slot, so no locking is needed
+				results[index] = propagateSatellite(ctx, catalog[index], times)
+			}
+		}()
+	}
+
+	// Feed satellite indices until the catalog is exhausted or the context is cancelled
+feed:
+	for index := range catalog {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Satellites that were never scheduled report the cancellation
+	if err := ctx.Err(); err != nil {
+		for index := range results {
+			if results[index].Satellite == "" {
+				results[index] = Result{Satellite: catalog[index].Name, Err: err}
+			}
+		}
+		return results, err
+	}
+
+	return results, nil
+}
+
+// propagateSatellite propagates a single satellite over all times, converting
+// invalid elements, non-finite positions and panics into an error
+func propagateSatellite(ctx context.Context, sat model.Satellite, times []time.Time) (result Result) {
+	result.Satellite = sat.Name
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.Locations = nil
+			result.Err = fmt.Errorf("propagation failed: %v", r)
+		}
+	}()
+
+	propagator, err := orbital.NewPropagator(sat.Elements)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Locations = make([]*model.SatLocation, 0, len(times))
+	for _, t := range times {
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			return result
+		}
+
+		location := propagator.Location(t)
+		if math.IsNaN(location.X) || math.IsInf(location.X, 0) {
+			result.Err = fmt.Errorf("non-finite position at %s", t.Format(time.RFC3339))
+			return result
+		}
+		location.Warnings = orbital.CheckElementHealth(sat.Elements, t)
+		location.InShadow = propagator.Illumination(t, orbital.ShadowConical).State != orbital.Sunlit
+
+		result.Locations = append(result.Locations, &location)
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package brightness
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// StandardRange is the slant range at which standard magnitudes are defined [km]
+const StandardRange = 1000.0
+
+// minPhaseFunction bounds the phase function away from zero, which it reaches at a
+// phase angle of pi, so that magnitudes stay finite (15 magnitudes fainter at most)
+const minPhaseFunction = 1e-6
+
+// PhaseFunction returns the brightness of a diffusely reflecting (Lambertian) sphere
+// at a phase angle [Rad], relative to its brightness at a phase angle of 90 degrees
+func PhaseFunction(phase float64) float64 {
+	return (math.Pi-phase)*math.Cos(phase) + math.Sin(phase)
+}
+
+// Magnitude returns the apparent visual magnitude of a satellite with the given
+// standard magnitude (at StandardRange and 90 degrees phase) seen at a slant range
+// [km] and phase angle [Rad]
+func Magnitude(standard, slantRange, phase float64) float64 {
+	phaseFunction := math.Max(PhaseFunction(phase), minPhaseFunction)
+	return standard + 5*math.Log10(slantRange/StandardRange) - 2.5*math.Log10(phaseFunction)
+}
+
+// PhaseAngle returns the Sun-satellite-observer angle [Rad]: 0 when the observer sees
+// the fully lit side of the satellite and pi when the Sun is directly behind it.
+// All positions must be in the same frame.
+func PhaseAngle(satellite, sun, observerPosition vecmath.Vec3) float64 {
+	toSun := sun.Sub(satellite).Unit()
+	toObserver := observerPosition.Sub(satellite).Unit()
+	return math.Acos(math.Max(-1, math.Min(1, toSun.Dot(toObserver))))
+}
+
+// Estimate returns the apparent visual magnitude of the satellite seen from the
+// station at targetTime. The brightness is reduced by the visible fraction of the
+// solar disc in penumbra; in umbra the satellite is not lit and false is returned.
+func Estimate(p *orbital.Propagator, station *observer.Station, targetTime time.Time, standard float64) (float64, bool) {
+	sun := orbital.SunPosition(targetTime)
+	illumination := orbital.CalculateIllumination(p.PositionECI(targetTime), sun, orbital.ShadowConical)
+	if illumination.State == orbital.Umbra || illumination.Fraction <= 0 {
+		return math.NaN(), false
+	}
+
+	// Evaluate the geometry in the Earth-fixed frame, where the station is fixed
+	state := p.StateECEF(targetTime)
+	satellite := vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}
+	sunFixed := orbital.EquatorialToEarthFixed(sun, targetTime)
+	slantRange := satellite.Sub(station.Position()).Norm()
+
+	magnitude := Magnitude(standard, slantRange, PhaseAngle(satellite, sunFixed, station.Position()))
+	return magnitude - 2.5*math.Log10(illumination.Fraction), true
+}
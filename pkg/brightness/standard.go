@@ -0,0 +1,129 @@
+package brightness
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Generation is a Starlink satellite design, which determines its typical brightness
+type Generation int
+
+const (
+	// GenerationV09 is the 2019 v0.9 test batch
+	GenerationV09 Generation = iota
+	// GenerationV10 is the first operational design (2019-2021)
+	GenerationV10
+	// GenerationV15 is the design with inter-satellite links (2021-2023)
+	GenerationV15
+	// GenerationV2Mini is the second-generation design (2023-)
+	GenerationV2Mini
+)
+
+// String returns the name of the generation
+func (g Generation) String() string {
+	switch g {
+	case GenerationV09:
+		return "v0.9"
+	case GenerationV15:
+		return "v1.5"
+	case GenerationV2Mini:
+		return "v2 mini"
+	default:
+		return "v1.0"
+	}
+}
+
+// StandardMagnitude returns the typical standard magnitude of the generation, at
+// StandardRange and 90 degrees phase. The values are averages of published visual
+// and photometric observations; individual satellites vary by about a magnitude
+// with attitude and brightness mitigation.
+func (g Generation) StandardMagnitude() float64 {
+	switch g {
+	case GenerationV09:
+		return 4.7
+	case GenerationV15:
+		return 6.8
+	case GenerationV2Mini:
+		return 7.2
+	default:
+		return 5.9
+	}
+}
+
+// StarlinkGeneration guesses the generation of a Starlink satellite from the number
+// in its catalog name (e.g. STARLINK-1008). Names that do not follow the pattern
+// are assumed to be v1.0.
+func StarlinkGeneration(name string) Generation {
+	number, ok := strings.CutPrefix(strings.ToUpper(strings.TrimSpace(name)), "STARLINK-")
+	if !ok {
+		return GenerationV10
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return GenerationV10
+	}
+
+	switch {
+	case n < 1000:
+		return GenerationV09
+	case n < 2000:
+		return GenerationV10
+	case n < 30000:
+		return GenerationV15
+	default:
+		return GenerationV2Mini
+	}
+}
+
+// Magnitudes holds configured standard magnitudes. Satellites without a configured
+// value use the default of their Starlink generation.
+type Magnitudes struct {
+	All    *float64           // Standard magnitude for every satellite, optional
+	ByName map[string]float64 // Standard magnitude by satellite name, overrides All
+}
+
+// For returns the standard magnitude of the named satellite. A nil receiver uses
+// generation defaults only.
+func (m *Magnitudes) For(name string) float64 {
+	if m != nil {
+		if value, ok := m.ByName[name]; ok {
+			return value
+		}
+		if m.All != nil {
+			return *m.All
+		}
+	}
+	return StarlinkGeneration(name).StandardMagnitude()
+}
+
+// ParseMagnitudes parses a comma-separated list of standard magnitudes, each either
+// a number applied to every satellite or NAME=number for a single satellite,
+// e.g. "6.5,STARLINK-1008=5.2"
+func ParseMagnitudes(value string) (*Magnitudes, error) {
+	m := &Magnitudes{ByName: make(map[string]float64)}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, number, named := strings.Cut(part, "=")
+		if !named {
+			number = part
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid magnitude %q: %w", part, err)
+		}
+
+		if named {
+			m.ByName[strings.TrimSpace(name)] = v
+		} else {
+			m.All = &v
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,226 @@
+// Package cdm writes and reads CCSDS Conjunction Data Messages (CCSDS 508.0-B-1) in
+// the keyword = value (KVN) and XML encodings.
+package cdm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"starlink/pkg/conjunction"
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// Version is the CDM version written
+const Version = "1.0"
+
+// ProbabilityMethod names the method of the probabilities written from conjunctions
+const ProbabilityMethod = "FOSTER-1992"
+
+// Format selects the encoding of a CDM
+type Format int
+
+const (
+	// FormatKVN is the keyword = value text encoding
+	FormatKVN Format = iota
+	// FormatXML is the XML encoding
+	FormatXML
+)
+
+// String returns the name of the format as accepted by ParseFormat
+func (f Format) String() string {
+	if f == FormatXML {
+		return "xml"
+	}
+	return "kvn"
+}
+
+// Extension returns the file name extension of the format
+func (f Format) Extension() string {
+	if f == FormatXML {
+		return ".xml"
+	}
+	return ".cdm"
+}
+
+// ParseFormat parses a format name (kvn or xml)
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "kvn":
+		return FormatKVN, nil
+	case "xml":
+		return FormatXML, nil
+	default:
+		return 0, fmt.Errorf("unknown CDM format %q (expected kvn or xml)", name)
+	}
+}
+
+// Message is a Conjunction Data Message. Relative quantities are given in the radial,
+// transverse (in-track) and normal (cross-track) frame of the first object, in the
+// meters of the standard.
+type Message struct {
+	CreationDate time.Time // Creation time (UTC)
+	Originator   string    // Creating agency or operator
+	MessageFor   string    // Spacecraft or operator the message is for, optional
+	MessageID    string    // Identifier unique to the originator
+
+	TCA           time.Time // Time of closest approach (UTC)
+	MissDistance  float64   // Distance at TCA [m]
+	RelativeSpeed float64   // Relative speed at TCA [m/s], zero when not given
+
+	HasRelativeState bool         // The relative position and velocity are given
+	RelativePosition vecmath.Vec3 // Position of object 2 relative to object 1 in RTN [m]
+	RelativeVelocity vecmath.Vec3 // Velocity of object 2 relative to object 1 in RTN [m/s]
+
+	CollisionProbability float64 // Probability of collision, when ProbabilityMethod is set
+	ProbabilityMethod    string  // Method of the probability, empty when not given
+
+	Objects [2]Object // OBJECT1 and OBJECT2
+}
+
+// Object is the metadata and data of one object of a CDM
+type Object struct {
+	Designator              string            // Catalog number
+	CatalogName             string            // Catalog of the designator, e.g. SATCAT
+	Name                    string            // Object name
+	InternationalDesignator string            // International designator, e.g. 2019-074B
+	ObjectType              string            // PAYLOAD, ROCKET BODY, DEBRIS, UNKNOWN or OTHER
+	EphemerisName           string            // Ephemeris used, NONE when not from an ephemeris
+	CovarianceMethod        string            // CALCULATED or DEFAULT
+	Maneuverable            string            // YES, NO or N/A
+	RefFrame                string            // Reference frame of the state vector
+	State                   model.StateVector // State at TCA [km, km/s]
+
+	// Covariance of position and velocity in the object's RTN frame, ordered R, T, N,
+	// RDOT, TDOT, NDOT [m, m/s]
+	Covariance [6][6]float64
+}
+
+// PositionCovariance returns the position block of the covariance in the object's
+// RTN frame [km2]
+func (o Object) PositionCovariance() vecmath.Mat3 {
+	var m vecmath.Mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[3*i+j] = o.Covariance[i][j] * 1e-6
+		}
+	}
+	return m
+}
+
+// FromConjunction builds the CDM of a screened conjunction. Objects are described by
+// the catalog number and international designator of their TLEs, with states rotated
+// into the Earth-fixed frame (ITRF, approximated by the sidereal rotation of the
+// propagator's equatorial frame). When the conjunction has been assessed its position
+// covariances and probability of collision are included; velocity covariances are not
+// modelled and written as zero.
+func FromConjunction(c conjunction.Conjunction, originator string, created time.Time) *Message {
+	relative := c.RelativeVelocity()
+	m := &Message{
+		CreationDate:     created.UTC(),
+		Originator:       originator,
+		MessageID:        MessageID(c),
+		TCA:              c.TCA.UTC(),
+		MissDistance:     c.MissDistance * 1000,
+		RelativeSpeed:    c.RelativeSpeed * 1000,
+		HasRelativeState: true,
+		RelativePosition: vecmath.Vec3{X: c.Radial, Y: c.InTrack, Z: c.CrossTrack}.Scale(1000),
+		RelativeVelocity: relative.Scale(1000),
+	}
+
+	assessed := c.PrimaryCovariance != (vecmath.Mat3{}) || c.SecondaryCovariance != (vecmath.Mat3{})
+	if assessed {
+		m.CollisionProbability = c.Probability
+		m.ProbabilityMethod = ProbabilityMethod
+	}
+
+	m.Objects[0] = newObject(c.Primary, c.PrimaryState, c.PrimaryCovariance)
+	m.Objects[1] = newObject(c.Secondary, c.SecondaryState, c.SecondaryCovariance)
+	return m
+}
+
+// newObject describes an object from its TLE, state and position covariance [km2]
+func newObject(sat model.Satellite, state model.StateVector, covariance vecmath.Mat3) Object {
+	o := Object{
+		CatalogName:      "SATCAT",
+		Name:             sat.Name,
+		ObjectType:       "UNKNOWN",
+		EphemerisName:    "NONE",
+		CovarianceMethod: "DEFAULT",
+		Maneuverable:     "N/A",
+		// The propagator's equatorial frame is not one that CDMs allow, so the state is
+		// given Earth-fixed; precession, nutation and polar motion are neglected
+		RefFrame: "ITRF",
+		State:    *orbital.EarthFixedState(&state),
+	}
+	if sat.Elements != nil {
+		o.Designator = sat.Elements.CatalogNumber
+		o.InternationalDesignator = FormatInternationalDesignator(sat.Elements.InternationalDesignator)
+	}
+	if strings.HasPrefix(sat.Name, "STARLINK") {
+		o.ObjectType = "PAYLOAD"
+		o.Maneuverable = "YES"
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			o.Covariance[i][j] = covariance[3*i+j] * 1e6
+		}
+	}
+	return o
+}
+
+// MessageID returns an identifier for the CDM of a conjunction built from the catalog
+// numbers (or names) of the objects and the TCA
+func MessageID(c conjunction.Conjunction) string {
+	return fmt.Sprintf("%s_%s_%s", objectID(c.Primary), objectID(c.Secondary), c.TCA.UTC().Format("20060102T150405"))
+}
+
+// objectID identifies an object in file names: its catalog number, or its name
+func objectID(sat model.Satellite) string {
+	if sat.Elements != nil && sat.Elements.CatalogNumber != "" {
+		return sat.Elements.CatalogNumber
+	}
+	return strings.NewReplacer(" ", "_", "/", "_", "[", "", "]", "").Replace(sat.Name)
+}
+
+// FormatInternationalDesignator converts a TLE international designator (e.g. 19074B)
+// to the form used by CDMs (e.g. 2019-074B). Two-digit years from 57 are in the 1900s.
+func FormatInternationalDesignator(designator string) string {
+	if len(designator) < 5 {
+		return designator
+	}
+	century := "20"
+	if designator[:2] >= "57" {
+		century = "19"
+	}
+	return century + designator[:2] + "-" + designator[2:]
+}
+
+// Write encodes a message in the given format
+func Write(w io.Writer, m *Message, format Format) error {
+	if format == FormatXML {
+		return WriteXML(w, m)
+	}
+	return WriteKVN(w, m)
+}
+
+// Read decodes a message, detecting its encoding: XML messages start with '<'
+func Read(r io.Reader) (*Message, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc *document
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "<") {
+		doc, err = parseXML(data)
+	} else {
+		doc, err = parseKVN(string(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.message()
+}
@@ -0,0 +1,304 @@
+package cdm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/vecmath"
+)
+
+// timeFormat is the CDM time format written (UTC, calendar date)
+const timeFormat = "2006-01-02T15:04:05.000000"
+
+// timeLayouts are the accepted CDM time formats: calendar date or day of year
+var timeLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-002T15:04:05.999999999"}
+
+// covarianceAxes name the rows and columns of the covariance in keywords
+var covarianceAxes = [6]string{"R", "T", "N", "RDOT", "TDOT", "NDOT"}
+
+// field is a keyword with its value and unit, the unit empty when dimensionless
+type field struct {
+	key, value, unit string
+}
+
+// segment holds the keywords of one object
+type segment struct {
+	metadata   []field // OBJECT, designators and frame
+	state      []field // Position and velocity
+	covariance []field // RTN covariance, lower triangle by rows
+}
+
+// document is a CDM as keywords, the form shared by the KVN and XML encodings
+type document struct {
+	header   []field    // CCSDS_CDM_VERS (KVN only), creation date, originator, message IDs
+	relative []field    // Relative metadata, including the RELATIVE_ state keywords
+	objects  [2]segment // OBJECT1 and OBJECT2
+}
+
+// newDocument lays a message out as keywords in the order of the standard
+func newDocument(m *Message) *document {
+	d := &document{}
+	d.header = []field{
+		{key: "CREATION_DATE", value: formatTime(m.CreationDate)},
+		{key: "ORIGINATOR", value: m.Originator},
+	}
+	if m.MessageFor != "" {
+		d.header = append(d.header, field{key: "MESSAGE_FOR", value: m.MessageFor})
+	}
+	d.header = append(d.header, field{key: "MESSAGE_ID", value: m.MessageID})
+
+	d.relative = []field{
+		{key: "TCA", value: formatTime(m.TCA)},
+		{key: "MISS_DISTANCE", value: formatFloat(m.MissDistance, 3), unit: "m"},
+	}
+	if m.RelativeSpeed != 0 {
+		d.relative = append(d.relative, field{key: "RELATIVE_SPEED", value: formatFloat(m.RelativeSpeed, 3), unit: "m/s"})
+	}
+	if m.HasRelativeState {
+		d.relative = append(d.relative,
+			field{key: "RELATIVE_POSITION_R", value: formatFloat(m.RelativePosition.X, 3), unit: "m"},
+			field{key: "RELATIVE_POSITION_T", value: formatFloat(m.RelativePosition.Y, 3), unit: "m"},
+			field{key: "RELATIVE_POSITION_N", value: formatFloat(m.RelativePosition.Z, 3), unit: "m"},
+			field{key: "RELATIVE_VELOCITY_R", value: formatFloat(m.RelativeVelocity.X, 3), unit: "m/s"},
+			field{key: "RELATIVE_VELOCITY_T", value: formatFloat(m.RelativeVelocity.Y, 3), unit: "m/s"},
+			field{key: "RELATIVE_VELOCITY_N", value: formatFloat(m.RelativeVelocity.Z, 3), unit: "m/s"})
+	}
+	if m.ProbabilityMethod != "" {
+		d.relative = append(d.relative,
+			field{key: "COLLISION_PROBABILITY", value: strconv.FormatFloat(m.CollisionProbability, 'E', 6, 64)},
+			field{key: "COLLISION_PROBABILITY_METHOD", value: m.ProbabilityMethod})
+	}
+
+	for i, o := range m.Objects {
+		s := &d.objects[i]
+		s.metadata = []field{
+			{key: "OBJECT", value: fmt.Sprintf("OBJECT%d", i+1)},
+			{key: "OBJECT_DESIGNATOR", value: o.Designator},
+			{key: "CATALOG_NAME", value: o.CatalogName},
+			{key: "OBJECT_NAME", value: o.Name},
+			{key: "INTERNATIONAL_DESIGNATOR", value: o.InternationalDesignator},
+			{key: "OBJECT_TYPE", value: o.ObjectType},
+			{key: "EPHEMERIS_NAME", value: o.EphemerisName},
+			{key: "COVARIANCE_METHOD", value: o.CovarianceMethod},
+			{key: "MANEUVERABLE", value: o.Maneuverable},
+			{key: "REF_FRAME", value: o.RefFrame},
+		}
+		s.state = []field{
+			{key: "X", value: formatFloat(o.State.X, 6), unit: "km"},
+			{key: "Y", value: formatFloat(o.State.Y, 6), unit: "km"},
+			{key: "Z", value: formatFloat(o.State.Z, 6), unit: "km"},
+			{key: "X_DOT", value: formatFloat(o.State.VX, 9), unit: "km/s"},
+			{key: "Y_DOT", value: formatFloat(o.State.VY, 9), unit: "km/s"},
+			{key: "Z_DOT", value: formatFloat(o.State.VZ, 9), unit: "km/s"},
+		}
+		for row := range covarianceAxes {
+			for col := 0; col <= row; col++ {
+				s.covariance = append(s.covariance, field{
+					key:   covarianceKey(row, col),
+					value: strconv.FormatFloat(o.Covariance[row][col], 'E', 6, 64),
+					unit:  covarianceUnit(row, col),
+				})
+			}
+		}
+	}
+
+	return d
+}
+
+// message decodes the keywords of a document
+func (d *document) message() (*Message, error) {
+	m := &Message{}
+	header := newValues(d.header, d.relative)
+
+	var err error
+	if m.CreationDate, err = header.time("CREATION_DATE"); err != nil {
+		return nil, err
+	}
+	if m.Originator, err = header.text("ORIGINATOR"); err != nil {
+		return nil, err
+	}
+	if m.MessageID, err = header.text("MESSAGE_ID"); err != nil {
+		return nil, err
+	}
+	m.MessageFor = header.optional("MESSAGE_FOR")
+	if m.TCA, err = header.time("TCA"); err != nil {
+		return nil, err
+	}
+	if m.MissDistance, err = header.float("MISS_DISTANCE"); err != nil {
+		return nil, err
+	}
+	if header.has("RELATIVE_SPEED") {
+		if m.RelativeSpeed, err = header.float("RELATIVE_SPEED"); err != nil {
+			return nil, err
+		}
+	}
+	if header.has("RELATIVE_POSITION_R") {
+		m.HasRelativeState = true
+		if m.RelativePosition, err = header.vector("RELATIVE_POSITION_R", "RELATIVE_POSITION_T", "RELATIVE_POSITION_N"); err != nil {
+			return nil, err
+		}
+		if m.RelativeVelocity, err = header.vector("RELATIVE_VELOCITY_R", "RELATIVE_VELOCITY_T", "RELATIVE_VELOCITY_N"); err != nil {
+			return nil, err
+		}
+	}
+	if header.has("COLLISION_PROBABILITY") {
+		if m.CollisionProbability, err = header.float("COLLISION_PROBABILITY"); err != nil {
+			return nil, err
+		}
+		m.ProbabilityMethod = header.optional("COLLISION_PROBABILITY_METHOD")
+		if m.ProbabilityMethod == "" {
+			m.ProbabilityMethod = "UNKNOWN"
+		}
+	}
+
+	for i := range d.objects {
+		s := d.objects[i]
+		if len(s.metadata) == 0 {
+			return nil, fmt.Errorf("missing OBJECT%d", i+1)
+		}
+		values := newValues(s.metadata, s.state, s.covariance)
+		o := &m.Objects[i]
+		if o.Designator, err = values.text("OBJECT_DESIGNATOR"); err != nil {
+			return nil, fmt.Errorf("OBJECT%d: %w", i+1, err)
+		}
+		o.CatalogName = values.optional("CATALOG_NAME")
+		o.Name = values.optional("OBJECT_NAME")
+		o.InternationalDesignator = values.optional("INTERNATIONAL_DESIGNATOR")
+		o.ObjectType = values.optional("OBJECT_TYPE")
+		o.EphemerisName = values.optional("EPHEMERIS_NAME")
+		o.CovarianceMethod = values.optional("COVARIANCE_METHOD")
+		o.Maneuverable = values.optional("MANEUVERABLE")
+		o.RefFrame = values.optional("REF_FRAME")
+
+		position, err := values.vector("X", "Y", "Z")
+		if err != nil {
+			return nil, fmt.Errorf("OBJECT%d: %w", i+1, err)
+		}
+		velocity, err := values.vector("X_DOT", "Y_DOT", "Z_DOT")
+		if err != nil {
+			return nil, fmt.Errorf("OBJECT%d: %w", i+1, err)
+		}
+		o.State.Time = m.TCA
+		o.State.X, o.State.Y, o.State.Z = position.X, position.Y, position.Z
+		o.State.VX, o.State.VY, o.State.VZ = velocity.X, velocity.Y, velocity.Z
+
+		// Covariance terms that are not given are left at zero
+		for row := range covarianceAxes {
+			for col := 0; col <= row; col++ {
+				key := covarianceKey(row, col)
+				if !values.has(key) {
+					continue
+				}
+				if o.Covariance[row][col], err = values.float(key); err != nil {
+					return nil, fmt.Errorf("OBJECT%d: %w", i+1, err)
+				}
+				o.Covariance[col][row] = o.Covariance[row][col]
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// values looks up decoded keywords
+type values map[string]string
+
+// newValues indexes the keywords of several field lists
+func newValues(lists ...[]field) values {
+	v := make(values)
+	for _, list := range lists {
+		for _, f := range list {
+			v[f.key] = f.value
+		}
+	}
+	return v
+}
+
+// has reports whether a keyword is present with a value
+func (v values) has(key string) bool {
+	return v[key] != ""
+}
+
+// optional returns the value of a keyword, empty when absent
+func (v values) optional(key string) string {
+	return v[key]
+}
+
+// text returns the value of a mandatory keyword
+func (v values) text(key string) (string, error) {
+	if !v.has(key) {
+		return "", fmt.Errorf("missing %s", key)
+	}
+	return v[key], nil
+}
+
+// float parses the value of a mandatory numeric keyword
+func (v values) float(key string) (float64, error) {
+	value, err := v.text(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return f, nil
+}
+
+// vector parses three mandatory numeric keywords
+func (v values) vector(x, y, z string) (vecmath.Vec3, error) {
+	var r vecmath.Vec3
+	var err error
+	if r.X, err = v.float(x); err != nil {
+		return r, err
+	}
+	if r.Y, err = v.float(y); err != nil {
+		return r, err
+	}
+	r.Z, err = v.float(z)
+	return r, err
+}
+
+// time parses the value of a mandatory time keyword
+func (v values) time(key string) (time.Time, error) {
+	value, err := v.text(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q", key, value)
+}
+
+// covarianceKey returns the keyword of a covariance term, e.g. CT_R
+func covarianceKey(row, col int) string {
+	return "C" + covarianceAxes[row] + "_" + covarianceAxes[col]
+}
+
+// covarianceUnit returns the unit of a covariance term
+func covarianceUnit(row, col int) string {
+	switch {
+	case row >= 3 && col >= 3:
+		return "m**2/s**2"
+	case row >= 3 || col >= 3:
+		return "m**2/s"
+	default:
+		return "m**2"
+	}
+}
+
+// formatTime formats a CDM time in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// formatFloat formats a fixed-point value
+func formatFloat(f float64, decimals int) string {
+	return strconv.FormatFloat(f, 'f', decimals, 64)
+}
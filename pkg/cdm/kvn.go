@@ -0,0 +1,87 @@
+package cdm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteKVN writes a message in the keyword = value encoding
+func WriteKVN(w io.Writer, m *Message) error {
+	d := newDocument(m)
+	bw := bufio.NewWriter(w)
+
+	writeFields(bw, []field{{key: "CCSDS_CDM_VERS", value: Version}})
+	writeFields(bw, d.header)
+	writeFields(bw, d.relative)
+	for _, s := range d.objects {
+		bw.WriteString("\n")
+		writeFields(bw, s.metadata)
+		writeFields(bw, s.state)
+		writeFields(bw, s.covariance)
+	}
+
+	return bw.Flush()
+}
+
+// writeFields writes one keyword per line, with the unit in brackets
+func writeFields(w *bufio.Writer, fields []field) {
+	for _, f := range fields {
+		if f.unit != "" {
+			fmt.Fprintf(w, "%-36s = %s [%s]\n", f.key, f.value, f.unit)
+		} else {
+			fmt.Fprintf(w, "%-36s = %s\n", f.key, f.value)
+		}
+	}
+}
+
+// parseKVN splits a keyword = value message into its header and object segments.
+// Keywords before the first OBJECT belong to the header and relative metadata, and
+// those after it to that object.
+func parseKVN(data string) (*document, error) {
+	d := &document{}
+	current := -1
+
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected keyword = value", n+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		// Drop the unit, e.g. "123.4 [m]"
+		if i := strings.Index(value, "["); i >= 0 && strings.HasSuffix(value, "]") {
+			value = strings.TrimSpace(value[:i])
+		}
+
+		if key == "OBJECT" {
+			switch value {
+			case "OBJECT1":
+				current = 0
+			case "OBJECT2":
+				current = 1
+			default:
+				return nil, fmt.Errorf("line %d: unknown OBJECT %q", n+1, value)
+			}
+		}
+
+		f := field{key: key, value: value}
+		if current < 0 {
+			d.header = append(d.header, f)
+		} else {
+			d.objects[current].metadata = append(d.objects[current].metadata, f)
+		}
+	}
+
+	if len(d.header) == 0 || d.header[0].key != "CCSDS_CDM_VERS" {
+		return nil, errors.New("not a CDM: missing CCSDS_CDM_VERS")
+	}
+	return d, nil
+}
@@ -0,0 +1,142 @@
+package cdm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteXML writes a message in the XML encoding
+func WriteXML(w io.Writer, m *Message) error {
+	d := newDocument(m)
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(xml.Header)
+	fmt.Fprintf(bw, "<cdm id=\"CCSDS_CDM_VERS\" version=\"%s\">\n", Version)
+	bw.WriteString("  <header>\n")
+	writeElements(bw, d.header, 4)
+	bw.WriteString("  </header>\n  <body>\n    <relativeMetadataData>\n")
+
+	// The relative position and velocity are grouped after the relative speed
+	var relativeState []field
+	for _, f := range d.relative {
+		if strings.HasPrefix(f.key, "RELATIVE_POSITION_") || strings.HasPrefix(f.key, "RELATIVE_VELOCITY_") {
+			relativeState = append(relativeState, f)
+			continue
+		}
+		if strings.HasPrefix(f.key, "COLLISION_PROBABILITY") && len(relativeState) > 0 {
+			writeGroup(bw, "relativeStateVector", relativeState, 6)
+			relativeState = nil
+		}
+		writeElements(bw, []field{f}, 6)
+	}
+	if len(relativeState) > 0 {
+		writeGroup(bw, "relativeStateVector", relativeState, 6)
+	}
+	bw.WriteString("    </relativeMetadataData>\n")
+
+	for _, s := range d.objects {
+		bw.WriteString("    <segment>\n")
+		writeGroup(bw, "metadata", s.metadata, 6)
+		bw.WriteString("      <data>\n")
+		writeGroup(bw, "stateVector", s.state, 8)
+		writeGroup(bw, "covarianceMatrix", s.covariance, 8)
+		bw.WriteString("      </data>\n    </segment>\n")
+	}
+	bw.WriteString("  </body>\n</cdm>\n")
+
+	return bw.Flush()
+}
+
+// writeGroup writes fields as the children of an element
+func writeGroup(w *bufio.Writer, name string, fields []field, indent int) {
+	pad := strings.Repeat(" ", indent)
+	fmt.Fprintf(w, "%s<%s>\n", pad, name)
+	writeElements(w, fields, indent+2)
+	fmt.Fprintf(w, "%s</%s>\n", pad, name)
+}
+
+// writeElements writes one element per field, with the unit as an attribute
+func writeElements(w *bufio.Writer, fields []field, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, f := range fields {
+		var value bytes.Buffer
+		xml.EscapeText(&value, []byte(f.value))
+		if f.unit != "" {
+			fmt.Fprintf(w, "%s<%s units=\"%s\">%s</%s>\n", pad, f.key, f.unit, value.String(), f.key)
+		} else {
+			fmt.Fprintf(w, "%s<%s>%s</%s>\n", pad, f.key, value.String(), f.key)
+		}
+	}
+}
+
+// parseXML collects the leaf elements of an XML message. Leaves inside the n-th
+// segment element belong to object n; all others to the header and relative metadata.
+func parseXML(data []byte) (*document, error) {
+	d := &document{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var path []string
+	var text strings.Builder
+	leaf := false
+	segment := -1
+	root := ""
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CDM XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root == "" {
+				root = t.Name.Local
+			}
+			if t.Name.Local == "segment" {
+				segment++
+				if segment > 1 {
+					return nil, errors.New("CDM has more than two segments")
+				}
+			}
+			path = append(path, t.Name.Local)
+			text.Reset()
+			leaf = true
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if leaf && t.Name.Local != "COMMENT" {
+				f := field{key: t.Name.Local, value: strings.TrimSpace(text.String())}
+				if segment < 0 || !inside(path, "segment") {
+					d.header = append(d.header, f)
+				} else {
+					d.objects[segment].metadata = append(d.objects[segment].metadata, f)
+				}
+			}
+			path = path[:len(path)-1]
+			leaf = false
+		}
+	}
+
+	if root != "cdm" {
+		return nil, fmt.Errorf("not a CDM: root element %q", root)
+	}
+	return d, nil
+}
+
+// inside reports whether an element path passes through the named element
+func inside(path []string, name string) bool {
+	for _, element := range path {
+		if element == name {
+			return true
+		}
+	}
+	return false
+}
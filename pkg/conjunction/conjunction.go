@@ -0,0 +1,379 @@
+package conjunction
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// DefaultThreshold is the screening distance [km]
+const DefaultThreshold = 5.0
+
+// DefaultStep is the sampling interval of the screening sweep
+const DefaultStep = 20 * time.Second
+
+// MaxRelativeSpeed bounds the relative speed of two LEO objects [km/s]. Between samples
+// two objects close by at most MaxRelativeSpeed * Step / 2, which pads the sweep distance
+// so that no encounter below the threshold falls between samples.
+const MaxRelativeSpeed = 16.0
+
+// Tolerance is the precision to which the time of closest approach is refined
+const Tolerance = time.Millisecond
+
+// Conjunction is a close approach between two objects
+type Conjunction struct {
+	Primary        model.Satellite   // First object
+	Secondary      model.Satellite   // Second object
+	TCA            time.Time         // Time of closest approach (UTC)
+	MissDistance   float64           // Distance at TCA [km]
+	RelativeSpeed  float64           // Relative speed at TCA [km/s]
+	PrimaryState   model.StateVector // Primary state at TCA in the equatorial frame
+	SecondaryState model.StateVector // Secondary state at TCA in the equatorial frame
+	Radial         float64           // Miss vector component along the primary's radius [km]
+	InTrack        float64           // Miss vector component along the primary's motion [km]
+	CrossTrack     float64           // Miss vector component along the primary's orbit normal [km]
+
+	// Set by Assess
+	PrimaryCovariance   vecmath.Mat3 // Primary position covariance in its radial/in-track/cross-track frame [km2]
+	SecondaryCovariance vecmath.Mat3 // Secondary position covariance in its radial/in-track/cross-track frame [km2]
+	Probability         float64      // Probability of collision
+	Level               Level        // Alert level of the probability
+}
+
+// Options controls the screening
+type Options struct {
+	Start     time.Time     // Window start
+	Stop      time.Time     // Window stop
+	Threshold float64       // Screening distance [km], DefaultThreshold when zero
+	Step      time.Duration // Sweep sampling interval, DefaultStep when zero
+}
+
+// Stats summarises how the screening pruned the candidate pairs
+type Stats struct {
+	Objects        int // Objects that could be propagated
+	PairsTotal     int // All pairs to screen
+	PairsFiltered  int // Pairs rejected by the apogee/perigee filter
+	Candidates     int // Sweep detections refined to a closest approach
+	Conjunctions   int // Closest approaches below the threshold
+	SkippedObjects int // Objects whose elements could not be propagated
+}
+
+// object is a propagated catalog entry
+type object struct {
+	sat        model.Satellite
+	propagator *orbital.Propagator
+	perigee    float64      // Perigee radius [km]
+	apogee     float64      // Apogee radius [km]
+	secondary  bool         // From the secondary catalog
+	shared     bool         // A primary that is also in the secondary catalog
+	position   vecmath.Vec3 // Position at the current sample [km]
+	velocity   vecmath.Vec3 // Velocity at the current sample [km/s]
+}
+
+// Screen finds close approaches within the window. When secondaries is empty every pair
+// of primaries is screened; otherwise every primary is screened against every secondary.
+// Secondaries that are also primaries (by name) are screened as primaries only, so that
+// each pair is screened once: two primaries are paired when either is a secondary too.
+// Pairs whose altitude ranges cannot come within the threshold are rejected by an
+// apogee/perigee filter, and the remaining pairs are found with a sweep along the x axis
+// at each sample time. Encounters are refined to the time of closest approach by
+// golden-section search, and those whose closest approach falls within the window are
+// returned ranked by miss distance.
+func Screen(ctx context.Context, primaries, secondaries []model.Satellite, opts Options) ([]Conjunction, Stats, error) {
+	var stats Stats
+	if opts.Stop.Before(opts.Start) {
+		return nil, stats, errors.New("stop time is before start time")
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Step <= 0 {
+		opts.Step = DefaultStep
+	}
+
+	objects := prepare(primaries, false, &stats)
+	nPrimary := len(objects)
+	crossCatalog := len(secondaries) > 0
+	if crossCatalog {
+		index := make(map[string]int, nPrimary)
+		for i, o := range objects {
+			index[o.sat.Name] = i
+		}
+		var others []model.Satellite
+		for _, sat := range secondaries {
+			if i, ok := index[sat.Name]; ok {
+				objects[i].shared = true
+				continue
+			}
+			others = append(others, sat)
+		}
+		objects = append(objects, prepare(others, true, &stats)...)
+	}
+	stats.Objects = len(objects)
+
+	// Pairs that must be screened, for the statistics
+	for i := range objects {
+		for j := i + 1; j < len(objects); j++ {
+			if !screened(objects[i], objects[j], crossCatalog) {
+				continue
+			}
+			stats.PairsTotal++
+			if !altitudesOverlap(objects[i], objects[j], opts.Threshold) {
+				stats.PairsFiltered++
+			}
+		}
+	}
+
+	// Across catalogs, secondaries that cannot approach any primary need not be propagated
+	if crossCatalog {
+		kept := objects[:nPrimary]
+		for _, o := range objects[nPrimary:] {
+			for _, primary := range objects[:nPrimary] {
+				if altitudesOverlap(primary, o, opts.Threshold) {
+					kept = append(kept, o)
+					break
+				}
+			}
+		}
+		objects = kept
+	}
+
+	sweepDistance := opts.Threshold + MaxRelativeSpeed*opts.Step.Seconds()/2
+	order := make([]int, len(objects))
+	for i := range order {
+		order[i] = i
+	}
+
+	found := make(map[[2]int][]Conjunction)
+	for t := opts.Start; !t.After(opts.Stop); t = t.Add(opts.Step) {
+		if err := ctx.Err(); err != nil {
+			return nil, stats, err
+		}
+
+		for i := range objects {
+			state := objects[i].propagator.StateECI(t)
+			objects[i].position = vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}
+			objects[i].velocity = vecmath.Vec3{X: state.VX, Y: state.VY, Z: state.VZ}
+		}
+		sort.Slice(order, func(a, b int) bool {
+			return objects[order[a]].position.X < objects[order[b]].position.X
+		})
+
+		for a := range order {
+			oa := &objects[order[a]]
+			for b := a + 1; b < len(order); b++ {
+				ob := &objects[order[b]]
+				if ob.position.X-oa.position.X > sweepDistance {
+					break
+				}
+				if !screened(*oa, *ob, crossCatalog) || !altitudesOverlap(*oa, *ob, opts.Threshold) {
+					continue
+				}
+				if oa.position.Sub(ob.position).Norm() > sweepDistance {
+					continue
+				}
+				if linearMiss(oa, ob, opts.Step) > opts.Threshold+linearMargin {
+					continue
+				}
+
+				i, j := order[a], order[b]
+				if i > j {
+					i, j = j, i
+				}
+				key := [2]int{i, j}
+				if duplicate(found[key], t, opts.Step) {
+					continue
+				}
+
+				stats.Candidates++
+				c := refine(&objects[i], &objects[j], t.Add(-opts.Step), t.Add(opts.Step), opts.Step)
+				if c.MissDistance <= opts.Threshold && !c.TCA.Before(opts.Start) && !c.TCA.After(opts.Stop) &&
+					!duplicate(found[key], c.TCA, opts.Step) {
+					found[key] = append(found[key], c)
+				}
+			}
+		}
+	}
+
+	var result []Conjunction
+	for _, list := range found {
+		result = append(result, list...)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].MissDistance != result[b].MissDistance {
+			return result[a].MissDistance < result[b].MissDistance
+		}
+		return result[a].TCA.Before(result[b].TCA)
+	})
+	stats.Conjunctions = len(result)
+
+	return result, stats, nil
+}
+
+// prepare builds propagators for a catalog, skipping invalid element sets
+func prepare(catalog []model.Satellite, secondary bool, stats *Stats) []object {
+	objects := make([]object, 0, len(catalog))
+	for _, sat := range catalog {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			stats.SkippedObjects++
+			continue
+		}
+		a, e := p.SemiMajorAxis(), sat.Elements.Eccentricity
+		objects = append(objects, object{
+			sat:        sat,
+			propagator: p,
+			perigee:    a * (1 - e),
+			apogee:     a * (1 + e),
+			secondary:  secondary,
+		})
+	}
+	return objects
+}
+
+// screened reports whether a pair is to be screened: any pair within a single
+// catalog, or across catalogs primary-secondary pairs and pairs of primaries of which
+// one is also a secondary
+func screened(a, b object, crossCatalog bool) bool {
+	if crossCatalog && a.secondary == b.secondary && (a.secondary || !a.shared && !b.shared) {
+		return false
+	}
+	return a.sat.Name != b.sat.Name
+}
+
+// altitudesOverlap is the apogee/perigee filter: two orbits can only come within the
+// threshold if the higher perigee is at most the threshold above the lower apogee
+func altitudesOverlap(a, b object, threshold float64) bool {
+	return math.Max(a.perigee, b.perigee)-math.Min(a.apogee, b.apogee) <= threshold
+}
+
+// linearMargin bounds the error of linearMiss over a step [km]. The relative
+// acceleration of two objects a few hundred km apart is below 1e-3 km/s^2.
+const linearMargin = 1.0
+
+// linearMiss estimates the closest approach of two objects within a step of the
+// current sample by assuming straight-line relative motion [km]
+func linearMiss(a, b *object, step time.Duration) float64 {
+	r := b.position.Sub(a.position)
+	v := b.velocity.Sub(a.velocity)
+
+	tau := 0.0
+	if speed2 := v.Dot(v); speed2 > 0 {
+		tau = -r.Dot(v) / speed2
+	}
+	limit := step.Seconds()
+	tau = math.Max(-limit, math.Min(limit, tau))
+
+	return r.Add(v.Scale(tau)).Norm()
+}
+
+// duplicate reports whether an encounter near t is already known
+func duplicate(known []Conjunction, t time.Time, step time.Duration) bool {
+	for _, c := range known {
+		if d := c.TCA.Sub(t); d > -2*step && d < 2*step {
+			return true
+		}
+	}
+	return false
+}
+
+// refine locates the closest approach of two objects starting from [t0, t1]. When the
+// minimum lies on the edge of the interval the objects are still approaching (or already
+// receding), so the interval is shifted by a step in that direction.
+func refine(a, b *object, t0, t1 time.Time, step time.Duration) Conjunction {
+	distance := func(t time.Time) float64 {
+		return a.propagator.PositionECI(t).Sub(b.propagator.PositionECI(t)).Norm()
+	}
+
+	tca := minimum(t0, t1, distance)
+	for i := 0; i < maxShifts; i++ {
+		switch {
+		case tca.Sub(t0) < 2*Tolerance:
+			t0, t1 = t0.Add(-step), t1.Add(-step)
+		case t1.Sub(tca) < 2*Tolerance:
+			t0, t1 = t0.Add(step), t1.Add(step)
+		default:
+			return newConjunction(a.sat, b.sat, a.propagator, b.propagator, tca)
+		}
+		tca = minimum(t0, t1, distance)
+	}
+	return newConjunction(a.sat, b.sat, a.propagator, b.propagator, tca)
+}
+
+// maxShifts limits how far refine follows a minimum outside the initial interval
+const maxShifts = 10
+
+// newConjunction evaluates the encounter geometry of two objects at tca
+func newConjunction(primary, secondary model.Satellite, pa, pb *orbital.Propagator, tca time.Time) Conjunction {
+	sa, sb := pa.StateECI(tca), pb.StateECI(tca)
+	ra, va := stateVectors(sa)
+	rb, vb := stateVectors(sb)
+	miss := rb.Sub(ra)
+	relative := vb.Sub(va)
+
+	// Rows are the radial, in-track and cross-track unit vectors of the primary
+	ric := ricFrame(ra, va).MulVec(miss)
+
+	return Conjunction{
+		Primary:        primary,
+		Secondary:      secondary,
+		TCA:            tca.UTC(),
+		MissDistance:   miss.Norm(),
+		RelativeSpeed:  relative.Norm(),
+		PrimaryState:   sa,
+		SecondaryState: sb,
+		Radial:         ric.X,
+		InTrack:        ric.Y,
+		CrossTrack:     ric.Z,
+	}
+}
+
+// RelativeVelocity returns the velocity of the secondary relative to the primary at TCA
+// in the primary's radial, in-track and cross-track directions [km/s]
+func (c Conjunction) RelativeVelocity() vecmath.Vec3 {
+	ra, va := stateVectors(c.PrimaryState)
+	_, vb := stateVectors(c.SecondaryState)
+	return ricFrame(ra, va).MulVec(vb.Sub(va))
+}
+
+// Closest refines the closest approach of two objects near a time, such as the TCA
+// reported by another screening. The approach is searched within a sweep step of near
+// and followed outside it like any screened encounter.
+func Closest(primary, secondary model.Satellite, near time.Time) (Conjunction, error) {
+	var stats Stats
+	objects := prepare([]model.Satellite{primary, secondary}, false, &stats)
+	if stats.SkippedObjects > 0 {
+		return Conjunction{}, errors.New("elements cannot be propagated")
+	}
+	return refine(&objects[0], &objects[1], near.Add(-DefaultStep), near.Add(DefaultStep), DefaultStep), nil
+}
+
+// minimum finds the time at which fn is smallest in [t0, t1] by golden-section search
+func minimum(t0, t1 time.Time, fn func(time.Time) float64) time.Time {
+	const invPhi = 0.6180339887498949
+
+	a, b := 0.0, t1.Sub(t0).Seconds()
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+
+	c := b - invPhi*(b-a)
+	d := a + invPhi*(b-a)
+	fc, fd := fn(at(c)), fn(at(d))
+	for b-a > Tolerance.Seconds() {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - invPhi*(b-a)
+			fc = fn(at(c))
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invPhi*(b-a)
+			fd = fn(at(d))
+		}
+	}
+	return at((a + b) / 2)
+}
@@ -0,0 +1,214 @@
+package conjunction
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// DefaultHardBodyRadius is the combined radius of two Starlink-sized objects [km]
+const DefaultHardBodyRadius = 0.02
+
+// Default thresholds on the probability of collision
+const (
+	DefaultWarningThreshold = 1e-5
+	DefaultAlertThreshold   = 1e-4
+)
+
+// Default TLE position error model: one-sigma errors at epoch [km] and their growth
+// with epoch age [km/day] in the radial, in-track and cross-track directions. In-track
+// errors dominate and grow fastest because of drag and mean motion uncertainty.
+var (
+	TLESigmaAtEpoch = vecmath.Vec3{X: 0.1, Y: 0.5, Z: 0.2}
+	TLESigmaGrowth  = vecmath.Vec3{X: 0.05, Y: 1.0, Z: 0.1}
+)
+
+// integrationSteps is the number of radial and angular steps of the numerical
+// integration over the hard-body circle
+const integrationSteps = 64
+
+// Level is the alert level of a conjunction
+type Level int
+
+const (
+	// LevelNone means the probability is below the warning threshold
+	LevelNone Level = iota
+	// LevelWarning means the probability reaches the warning threshold
+	LevelWarning
+	// LevelAlert means the probability reaches the alert threshold
+	LevelAlert
+)
+
+// String returns the name of the alert level
+func (l Level) String() string {
+	switch l {
+	case LevelWarning:
+		return "warning"
+	case LevelAlert:
+		return "alert"
+	default:
+		return "none"
+	}
+}
+
+// Thresholds are the probabilities of collision at which conjunctions are flagged
+type Thresholds struct {
+	Warning float64 // Warning threshold, DefaultWarningThreshold when zero
+	Alert   float64 // Alert threshold, DefaultAlertThreshold when zero
+}
+
+// Classify returns the alert level of a probability of collision
+func (t Thresholds) Classify(probability float64) Level {
+	if t.Warning <= 0 {
+		t.Warning = DefaultWarningThreshold
+	}
+	if t.Alert <= 0 {
+		t.Alert = DefaultAlertThreshold
+	}
+	switch {
+	case probability >= t.Alert:
+		return LevelAlert
+	case probability >= t.Warning:
+		return LevelWarning
+	default:
+		return LevelNone
+	}
+}
+
+// CovarianceFunc returns the position covariance of an object at a time, in its
+// radial/in-track/cross-track frame [km2]
+type CovarianceFunc func(sat model.Satellite, t time.Time) vecmath.Mat3
+
+// AssessOptions controls the collision probability assessment
+type AssessOptions struct {
+	HardBodyRadius float64        // Combined hard-body radius [km], DefaultHardBodyRadius when zero
+	Covariance     CovarianceFunc // Covariance of each object, TLECovariance when nil
+	Thresholds     Thresholds     // Alert thresholds
+}
+
+// TLECovariance is the default covariance model: diagonal in the radial/in-track/
+// cross-track frame, with sigmas growing linearly with the age of the element set at t
+func TLECovariance(sat model.Satellite, t time.Time) vecmath.Mat3 {
+	age := math.Abs(t.Sub(orbital.EpochTime(sat.Elements)).Hours() / 24)
+	sigma := TLESigmaAtEpoch.Add(TLESigmaGrowth.Scale(age))
+	return vecmath.Diagonal(sigma.X*sigma.X, sigma.Y*sigma.Y, sigma.Z*sigma.Z)
+}
+
+// ConstantCovariance returns a covariance model with the same one-sigma errors [km] in
+// the radial, in-track and cross-track directions for every object
+func ConstantCovariance(sigma vecmath.Vec3) CovarianceFunc {
+	covariance := vecmath.Diagonal(sigma.X*sigma.X, sigma.Y*sigma.Y, sigma.Z*sigma.Z)
+	return func(model.Satellite, time.Time) vecmath.Mat3 {
+		return covariance
+	}
+}
+
+// Assess sets the covariances, probability of collision and alert level of each conjunction
+func Assess(conjunctions []Conjunction, opts AssessOptions) {
+	if opts.HardBodyRadius <= 0 {
+		opts.HardBodyRadius = DefaultHardBodyRadius
+	}
+	if opts.Covariance == nil {
+		opts.Covariance = TLECovariance
+	}
+
+	for i := range conjunctions {
+		c := &conjunctions[i]
+		c.PrimaryCovariance = opts.Covariance(c.Primary, c.TCA)
+		c.SecondaryCovariance = opts.Covariance(c.Secondary, c.TCA)
+		c.Probability = CollisionProbability(*c, c.PrimaryCovariance, c.SecondaryCovariance, opts.HardBodyRadius)
+		c.Level = opts.Thresholds.Classify(c.Probability)
+	}
+}
+
+// RankByProbability orders assessed conjunctions by decreasing probability of
+// collision, then by miss distance
+func RankByProbability(conjunctions []Conjunction) {
+	sort.SliceStable(conjunctions, func(a, b int) bool {
+		if conjunctions[a].Probability != conjunctions[b].Probability {
+			return conjunctions[a].Probability > conjunctions[b].Probability
+		}
+		return conjunctions[a].MissDistance < conjunctions[b].MissDistance
+	})
+}
+
+// CollisionProbability computes the probability of collision of a conjunction in the
+// encounter plane (Foster's method). The position covariances of the two objects, each
+// in its own radial/in-track/cross-track frame [km2], are combined in the equatorial
+// frame and projected onto the plane normal to the relative velocity, and the resulting
+// two-dimensional Gaussian is integrated numerically over the hard-body circle [km]
+// centred on the miss vector.
+func CollisionProbability(c Conjunction, primaryCovariance, secondaryCovariance vecmath.Mat3, hardBodyRadius float64) float64 {
+	ra, va := stateVectors(c.PrimaryState)
+	rb, vb := stateVectors(c.SecondaryState)
+	miss := rb.Sub(ra)
+	relative := vb.Sub(va)
+
+	// Combined covariance in the equatorial frame
+	toFixedA := ricFrame(ra, va).Transpose()
+	toFixedB := ricFrame(rb, vb).Transpose()
+	combined := toFixedA.Mul(primaryCovariance).Mul(toFixedA.Transpose()).
+		Add(toFixedB.Mul(secondaryCovariance).Mul(toFixedB.Transpose()))
+
+	// Encounter plane: x along the miss vector, y completing the frame with the
+	// relative velocity as normal
+	normal := relative.Unit()
+	x := miss.Sub(normal.Scale(miss.Dot(normal)))
+	if x.Norm() == 0 {
+		x = normal.Cross(vecmath.Vec3{Z: 1})
+		if x.Norm() == 0 {
+			x = normal.Cross(vecmath.Vec3{X: 1})
+		}
+	}
+	x = x.Unit()
+	y := normal.Cross(x)
+
+	sxx := x.Dot(combined.MulVec(x))
+	syy := y.Dot(combined.MulVec(y))
+	sxy := x.Dot(combined.MulVec(y))
+	det := sxx*syy - sxy*sxy
+	d := miss.Dot(x)
+
+	if det <= 0 {
+		// No usable uncertainty: the objects collide only if the miss is within the hard body
+		if math.Abs(d) <= hardBodyRadius {
+			return 1
+		}
+		return 0
+	}
+
+	// Integrate the Gaussian density over the hard-body circle in polar coordinates
+	// about the miss point, with the midpoint rule
+	ixx, iyy, ixy := syy/det, sxx/det, -sxy/det
+	dr := hardBodyRadius / integrationSteps
+	dTheta := 2 * math.Pi / integrationSteps
+	sum := 0.0
+	for i := 0; i < integrationSteps; i++ {
+		r := (float64(i) + 0.5) * dr
+		for j := 0; j < integrationSteps; j++ {
+			sinT, cosT := math.Sincos((float64(j) + 0.5) * dTheta)
+			px, py := d+r*cosT, r*sinT
+			sum += math.Exp(-0.5*(ixx*px*px+2*ixy*px*py+iyy*py*py)) * r
+		}
+	}
+
+	return sum * dr * dTheta / (2 * math.Pi * math.Sqrt(det))
+}
+
+// ricFrame returns the rotation from the equatorial frame to the radial/in-track/
+// cross-track frame of an object, whose rows are the three unit vectors
+func ricFrame(position, velocity vecmath.Vec3) vecmath.Mat3 {
+	radial := position.Unit()
+	crossTrack := position.Cross(velocity).Unit()
+	inTrack := crossTrack.Cross(radial)
+	return vecmath.Rows(radial, inTrack, crossTrack)
+}
+
+// stateVectors splits a state vector into position and velocity
+func stateVectors(state model.StateVector) (vecmath.Vec3, vecmath.Vec3) {
+	return vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}, vecmath.Vec3{X: state.VX, Y: state.VY, Z: state.VZ}
+}
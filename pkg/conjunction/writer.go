@@ -0,0 +1,41 @@
+package conjunction
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVWriter writes conjunctions as CSV rows
+type CSVWriter struct {
+	w           *bufio.Writer
+	wroteHeader bool
+}
+
+// NewCSVWriter creates a CSV writer for conjunction reports
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes a conjunction with its rank, preceded by the header on the first call
+func (cw *CSVWriter) Write(rank int, c Conjunction) error {
+	if !cw.wroteHeader {
+		header := "rank,primary,secondary,tca,miss_km,radial_km,in_track_km,cross_track_km,relative_speed_km_s,probability,level\n"
+		if _, err := cw.w.WriteString(header); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+
+	_, err := fmt.Fprintf(cw.w, "%d,%s,%s,%s,%.3f,%.3f,%.3f,%.3f,%.3f,%.3e,%s\n",
+		rank, c.Primary.Name, c.Secondary.Name, c.TCA.Format(time.RFC3339Nano),
+		c.MissDistance, c.Radial, c.InTrack, c.CrossTrack, c.RelativeSpeed,
+		c.Probability, c.Level)
+	return err
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (cw *CSVWriter) Flush() error {
+	return cw.w.Flush()
+}
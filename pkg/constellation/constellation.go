@@ -0,0 +1,399 @@
+// Package constellation classifies the satellites of a constellation into shells
+// (inclination and altitude), orbital planes (RAAN) and slots (argument of latitude),
+// and identifies satellites migrating between planes.
+package constellation
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+)
+
+// Default classification settings
+const (
+	DefaultInclinationTolerance = 0.15 // Half-width of a shell in inclination [degree]
+	DefaultAltitudeTolerance    = 8.0  // Half-width of a shell in altitude [km]
+	DefaultMinShellSize         = 20   // Fewest satellites of a shell
+	DefaultPlaneTolerance       = 1.0  // Half-width of a plane in RAAN [degree]
+	DefaultMinPlaneSize         = 3    // Fewest satellites of a plane
+	DefaultMigrationRate        = 0.01 // RAAN drift relative to the shell that marks a migration [degree/day]
+)
+
+// Options configure the classification. Zero values select the defaults.
+type Options struct {
+	InclinationTolerance float64 // Half-width of a shell in inclination [degree]
+	AltitudeTolerance    float64 // Half-width of a shell in altitude [km]
+	MinShellSize         int     // Fewest satellites of a shell
+	PlaneTolerance       float64 // Half-width of a plane in RAAN [degree]
+	MinPlaneSize         int     // Fewest satellites of a plane
+	MigrationRate        float64 // Relative RAAN drift that marks a migration [degree/day]
+}
+
+// Member is the classification of one satellite. Shell, plane and slot numbers start
+// at 1 and are 0 when the satellite is not assigned one.
+type Member struct {
+	Name               string  // Satellite name
+	Inclination        float64 // Inclination [degree]
+	Altitude           float64 // Mean altitude above the equator [km]
+	Raan               float64 // RAAN at the classification epoch [degree]
+	ArgumentOfLatitude float64 // Mean argument of latitude at the classification epoch [degree]
+	RaanDrift          float64 // RAAN drift relative to the shell [degree/day], zero outside shells
+
+	Shell      int     // Shell number, 0 for satellites in transit between altitudes
+	Plane      int     // Plane number within the shell, 0 between planes
+	Slot       int     // Slot number within the plane
+	SlotOffset float64 // Argument of latitude from the slot center [degree]
+
+	Migrating   bool // Whether the satellite is moving to another plane
+	TargetPlane int  // Plane the satellite is drifting toward, when migrating
+}
+
+// Plane is an orbital plane of a shell
+type Plane struct {
+	Number  int     // Plane number within the shell, in RAAN order
+	Raan    float64 // RAAN of the plane at the classification epoch [degree]
+	Members []int   // Indexes of the plane's satellites in Classification.Members, in slot order
+}
+
+// Shell is a group of satellites with the same inclination and altitude
+type Shell struct {
+	Number        int     // Shell number, in inclination and altitude order
+	Inclination   float64 // Median inclination [degree]
+	Altitude      float64 // Median mean altitude [km]
+	RaanRate      float64 // Median RAAN drift rate [degree/day]
+	SlotsPerPlane int     // Nominal number of slots per plane
+	Planes        []Plane // Planes in RAAN order
+	Members       []int   // Indexes of the shell's satellites in Classification.Members
+}
+
+// Classification is the shell, plane and slot structure of a constellation at an epoch
+type Classification struct {
+	Epoch   time.Time // Epoch to which the RAAN and argument of latitude are propagated
+	Shells  []Shell   // Shells in inclination and altitude order
+	Members []Member  // Satellites in input order, without invalid element sets
+}
+
+// Migrating returns the indexes of the satellites migrating between planes
+func (c Classification) Migrating() []int {
+	var indexes []int
+	for i, m := range c.Members {
+		if m.Migrating {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Sorted returns the indexes of the members ordered by shell, plane and slot, with
+// satellites outside shells (and then between planes) last
+func (c Classification) Sorted() []int {
+	last := func(n int) int {
+		if n == 0 {
+			return math.MaxInt
+		}
+		return n
+	}
+	indexes := make([]int, len(c.Members))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		ma, mb := c.Members[indexes[a]], c.Members[indexes[b]]
+		if ma.Shell != mb.Shell {
+			return last(ma.Shell) < last(mb.Shell)
+		}
+		if ma.Plane != mb.Plane {
+			return last(ma.Plane) < last(mb.Plane)
+		}
+		return ma.Slot < mb.Slot
+	})
+	return indexes
+}
+
+// Classify classifies satellites into shells, planes and slots. The element sets are
+// propagated to the epoch with the J2 secular rates and mean motion derivative, so
+// that RAANs that drift at different rates are compared at one time. Shells are the
+// densest groups in inclination and altitude, taken one at a time while they have at
+// least MinShellSize satellites; the rest are in transit (orbit raising or lowering).
+// Planes are likewise the densest RAAN groups of each shell. Slots divide each plane
+// evenly in argument of latitude, with the shell's median plane population as the
+// nominal number of slots. Satellites of a shell that are between planes, or whose RAAN
+// drifts away from the shell's faster than MigrationRate, are migrating.
+func Classify(satellites []model.Satellite, epoch time.Time, opts Options) Classification {
+	if opts.InclinationTolerance <= 0 {
+		opts.InclinationTolerance = DefaultInclinationTolerance
+	}
+	if opts.AltitudeTolerance <= 0 {
+		opts.AltitudeTolerance = DefaultAltitudeTolerance
+	}
+	if opts.MinShellSize <= 0 {
+		opts.MinShellSize = DefaultMinShellSize
+	}
+	if opts.PlaneTolerance <= 0 {
+		opts.PlaneTolerance = DefaultPlaneTolerance
+	}
+	if opts.MinPlaneSize <= 0 {
+		opts.MinPlaneSize = DefaultMinPlaneSize
+	}
+	if opts.MigrationRate <= 0 {
+		opts.MigrationRate = DefaultMigrationRate
+	}
+
+	c := Classification{Epoch: epoch}
+	var drifts []float64 // Absolute RAAN drift rates [degree/day]
+	for _, sat := range satellites {
+		p, err := orbital.NewPropagator(sat.Elements)
+		if err != nil {
+			continue
+		}
+		mean := p.MeanElementsAt(epoch)
+		nextDay := p.MeanElementsAt(epoch.Add(24 * time.Hour))
+		c.Members = append(c.Members, Member{
+			Name:               sat.Name,
+			Inclination:        mean.OrbitalInclination,
+			Altitude:           orbital.SemiMajorAxis(mean) - util.WGS84EquatorialRadius,
+			Raan:               mean.Raan,
+			ArgumentOfLatitude: math.Mod(mean.ArgumentOfPerigee+mean.MeanAnomaly, 360),
+		})
+		drifts = append(drifts, math.Remainder(nextDay.Raan-mean.Raan, 360))
+	}
+
+	c.Shells = findShells(c.Members, opts)
+	for s := range c.Shells {
+		shell := &c.Shells[s]
+		shell.Number = s + 1
+		rates := make([]float64, len(shell.Members))
+		for k, i := range shell.Members {
+			c.Members[i].Shell = shell.Number
+			rates[k] = drifts[i]
+		}
+		shell.RaanRate = util.Median(rates)
+		for _, i := range shell.Members {
+			c.Members[i].RaanDrift = drifts[i] - shell.RaanRate
+		}
+
+		shell.Planes = findPlanes(c.Members, shell.Members, opts)
+		assignSlots(c.Members, shell)
+		markMigrations(c.Members, shell, opts)
+	}
+	return c
+}
+
+// findShells groups members into shells by repeatedly taking the densest window in
+// inclination and altitude, and returns them in inclination and altitude order
+func findShells(members []Member, opts Options) []Shell {
+	remaining := make([]int, len(members))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	within := func(center Member, m Member) bool {
+		return math.Abs(m.Inclination-center.Inclination) <= opts.InclinationTolerance &&
+			math.Abs(m.Altitude-center.Altitude) <= opts.AltitudeTolerance
+	}
+
+	var shells []Shell
+	for len(remaining) >= opts.MinShellSize {
+		// Satellite with the most neighbours
+		best, bestCount := -1, 0
+		for _, i := range remaining {
+			count := 0
+			for _, j := range remaining {
+				if within(members[i], members[j]) {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+		if bestCount < opts.MinShellSize {
+			break
+		}
+
+		// Re-center the window on the median of the neighbours
+		var inclinations, altitudes []float64
+		for _, j := range remaining {
+			if within(members[best], members[j]) {
+				inclinations = append(inclinations, members[j].Inclination)
+				altitudes = append(altitudes, members[j].Altitude)
+			}
+		}
+		center := Member{Inclination: util.Median(inclinations), Altitude: util.Median(altitudes)}
+		count := 0
+		for _, j := range remaining {
+			if within(center, members[j]) {
+				count++
+			}
+		}
+		if count < opts.MinShellSize {
+			center = members[best] // Re-centering lost members; keep the original window
+		}
+
+		var shell Shell
+		inclinations, altitudes = inclinations[:0], altitudes[:0]
+		rest := remaining[:0]
+		for _, j := range remaining {
+			if within(center, members[j]) {
+				shell.Members = append(shell.Members, j)
+				inclinations = append(inclinations, members[j].Inclination)
+				altitudes = append(altitudes, members[j].Altitude)
+			} else {
+				rest = append(rest, j)
+			}
+		}
+		shell.Inclination, shell.Altitude = util.Median(inclinations), util.Median(altitudes)
+		remaining = rest
+		shells = append(shells, shell)
+	}
+
+	// Shells of nearby inclinations (to the degree) are ordered by altitude
+	sort.Slice(shells, func(a, b int) bool {
+		ia, ib := math.Round(shells[a].Inclination), math.Round(shells[b].Inclination)
+		if ia != ib {
+			return ia < ib
+		}
+		return shells[a].Altitude < shells[b].Altitude
+	})
+	return shells
+}
+
+// findPlanes groups the members of a shell into planes by repeatedly taking the
+// densest RAAN window, and returns them numbered in RAAN order
+func findPlanes(members []Member, indexes []int, opts Options) []Plane {
+	remaining := append([]int(nil), indexes...)
+	within := func(center float64, m Member) bool {
+		return math.Abs(math.Remainder(m.Raan-center, 360)) <= opts.PlaneTolerance
+	}
+
+	var planes []Plane
+	for len(remaining) >= opts.MinPlaneSize {
+		best, bestCount := -1, 0
+		for _, i := range remaining {
+			count := 0
+			for _, j := range remaining {
+				if within(members[i].Raan, members[j]) {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+		if bestCount < opts.MinPlaneSize {
+			break
+		}
+
+		// Center the plane on the mean RAAN of the window, which wraps around 360
+		var sinSum, cosSum float64
+		for _, j := range remaining {
+			if within(members[best].Raan, members[j]) {
+				sin, cos := math.Sincos(util.Deg2Rad(members[j].Raan))
+				sinSum += sin
+				cosSum += cos
+			}
+		}
+		center := math.Mod(util.Rad2Deg(math.Atan2(sinSum, cosSum))+360, 360)
+
+		plane := Plane{Raan: center}
+		rest := remaining[:0]
+		for _, j := range remaining {
+			if within(center, members[j]) {
+				plane.Members = append(plane.Members, j)
+			} else {
+				rest = append(rest, j)
+			}
+		}
+		if len(plane.Members) == 0 {
+			break
+		}
+		remaining = rest
+		planes = append(planes, plane)
+	}
+
+	sort.Slice(planes, func(a, b int) bool { return planes[a].Raan < planes[b].Raan })
+	for p := range planes {
+		planes[p].Number = p + 1
+		for _, i := range planes[p].Members {
+			members[i].Plane = p + 1
+		}
+	}
+	return planes
+}
+
+// assignSlots numbers the satellites of each plane of a shell by slot. The slots are
+// evenly spaced in argument of latitude with a phase fitted to the plane's satellites.
+func assignSlots(members []Member, shell *Shell) {
+	sizes := make([]float64, len(shell.Planes))
+	for p, plane := range shell.Planes {
+		sizes[p] = float64(len(plane.Members))
+	}
+	shell.SlotsPerPlane = int(math.Round(util.Median(sizes)))
+
+	for p := range shell.Planes {
+		plane := &shell.Planes[p]
+		slots := max(shell.SlotsPerPlane, len(plane.Members))
+		spacing := 360 / float64(slots)
+
+		// Phase of the slot pattern: the mean of the arguments of latitude modulo the spacing
+		var sinSum, cosSum float64
+		for _, i := range plane.Members {
+			sin, cos := math.Sincos(util.Deg2Rad(members[i].ArgumentOfLatitude * float64(slots)))
+			sinSum += sin
+			cosSum += cos
+		}
+		phase := util.Rad2Deg(math.Atan2(sinSum, cosSum)) / float64(slots)
+
+		for _, i := range plane.Members {
+			m := &members[i]
+			offset := math.Mod(m.ArgumentOfLatitude-phase+360, 360)
+			slot := int(math.Round(offset/spacing)) % slots
+			m.Slot = slot + 1
+			m.SlotOffset = math.Remainder(offset-float64(slot)*spacing, 360)
+		}
+		sort.SliceStable(plane.Members, func(a, b int) bool {
+			return members[plane.Members[a]].Slot < members[plane.Members[b]].Slot
+		})
+	}
+}
+
+// markMigrations flags the satellites of a shell that are between planes or drift
+// away from their plane, with the plane they are drifting toward
+func markMigrations(members []Member, shell *Shell, opts Options) {
+	if len(shell.Planes) == 0 {
+		return
+	}
+	for _, i := range shell.Members {
+		m := &members[i]
+		drifting := math.Abs(m.RaanDrift) > opts.MigrationRate
+		if m.Plane != 0 && !drifting {
+			continue
+		}
+		m.Migrating = true
+		m.TargetPlane = targetPlane(shell.Planes, m)
+	}
+}
+
+// targetPlane returns the number of the plane a satellite drifts toward: the nearest
+// plane ahead in the direction of its relative RAAN drift, other than its own
+func targetPlane(planes []Plane, m *Member) int {
+	direction := 1.0
+	if m.RaanDrift < 0 {
+		direction = -1
+	}
+	best, bestDistance := 0, math.Inf(1)
+	for _, plane := range planes {
+		if plane.Number == m.Plane {
+			continue
+		}
+		// RAAN distance to the plane in the drift direction, in [0, 360)
+		distance := math.Mod(direction*(plane.Raan-m.Raan)+360, 360)
+		if distance < bestDistance {
+			best, bestDistance = plane.Number, distance
+		}
+	}
+	return best
+}
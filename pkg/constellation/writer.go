@@ -0,0 +1,27 @@
+package constellation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteCSV writes the shell, plane and slot assignment of every satellite as CSV rows,
+// in the order of Sorted
+func WriteCSV(w io.Writer, c Classification) error {
+	bw := bufio.NewWriter(w)
+	header := "satellite,shell,plane,slot,slot_offset_deg,inclination_deg,altitude_km,raan_deg,argument_of_latitude_deg,raan_drift_deg_day,migrating,target_plane\n"
+	if _, err := bw.WriteString(header); err != nil {
+		return err
+	}
+
+	for _, i := range c.Sorted() {
+		m := c.Members[i]
+		if _, err := fmt.Fprintf(bw, "%s,%d,%d,%d,%.3f,%.4f,%.3f,%.4f,%.4f,%.5f,%t,%d\n",
+			m.Name, m.Shell, m.Plane, m.Slot, m.SlotOffset, m.Inclination, m.Altitude, m.Raan,
+			m.ArgumentOfLatitude, m.RaanDrift, m.Migrating, m.TargetPlane); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
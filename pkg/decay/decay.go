@@ -0,0 +1,305 @@
+// Package decay predicts the orbital decay and re-entry of satellites under
+// atmospheric drag, either by integrating the semi-major axis decay of a near-circular
+// orbit or by numerical propagation, with a solar-activity-driven atmosphere.
+package decay
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// Default prediction settings
+const (
+	DefaultHorizon            = 25 * 365 * 24 * time.Hour // Longest prediction
+	MaxNumericalHorizon       = 2 * 365 * 24 * time.Hour  // Longest numerical prediction
+	DefaultDensityUncertainty = 0.3                       // Relative density (and ballistic coefficient) uncertainty
+)
+
+// ReentryAltitude is the altitude at which the satellite is considered to have
+// re-entered, the same as for the numerical propagator [km]
+const ReentryAltitude = numerical.MinRadius - util.WGS84EquatorialRadius
+
+// bStarDensity is the reference density of the B* drag term [kg/m2/EarthRadii]:
+// B* = bStarDensity * B / 2 with the ballistic coefficient B = C_D A / m [m2/kg]
+const bStarDensity = 2.461e-5
+
+// numericalTolerance is the relative error per step of the numerical propagation,
+// looser than the numerical propagator's default as the decay is far less certain
+const numericalTolerance = 1e-8
+
+// Step control of the semi-major axis integration
+const (
+	maxDecayStep = 0.5          // Largest semi-major axis change per step [km]
+	minTimeStep  = 60.0         // Smallest step [s]
+	maxTimeStep  = 30 * 86400.0 // Largest step [s]
+)
+
+// Method selects how the decay is predicted
+type Method int
+
+const (
+	// MethodMeanMotionDot integrates the semi-major axis decay with a ballistic
+	// coefficient calibrated to the element set's mean motion derivative
+	MethodMeanMotionDot Method = iota
+	// MethodBStar integrates the semi-major axis decay with the ballistic coefficient
+	// of the element set's B* drag term
+	MethodBStar
+	// MethodNumerical propagates the orbit numerically under J2 and drag, with the
+	// ballistic coefficient of MethodMeanMotionDot, or of MethodBStar when the mean
+	// motion derivative shows no decay
+	MethodNumerical
+)
+
+// String returns the name of the method
+func (m Method) String() string {
+	switch m {
+	case MethodMeanMotionDot:
+		return "ndot"
+	case MethodBStar:
+		return "bstar"
+	case MethodNumerical:
+		return "numerical"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// ParseMethod parses a method name (ndot, bstar or numerical)
+func ParseMethod(name string) (Method, error) {
+	switch strings.ToLower(name) {
+	case "ndot":
+		return MethodMeanMotionDot, nil
+	case "bstar":
+		return MethodBStar, nil
+	case "numerical":
+		return MethodNumerical, nil
+	default:
+		return 0, fmt.Errorf("unknown decay method %q (expected ndot, bstar or numerical)", name)
+	}
+}
+
+// Options configure a decay prediction. The zero value integrates the mean motion
+// derivative decay under the default solar activity.
+type Options struct {
+	Method               Method
+	Atmosphere           numerical.SolarFlux // Solar activity of the atmosphere
+	BallisticCoefficient float64             // C_D A / m [m2/kg], derived from the element set when zero
+	DensityUncertainty   float64             // Relative density uncertainty of the bounds, DefaultDensityUncertainty when zero
+	Horizon              time.Duration       // Longest prediction, DefaultHorizon when zero
+}
+
+// Estimate is a predicted re-entry
+type Estimate struct {
+	Epoch                time.Time // Start of the prediction (element set epoch)
+	Method               Method    // Method used
+	Altitude             float64   // Mean altitude at the epoch [km]
+	DecayRate            float64   // Semi-major axis decay rate at the epoch [km/day]
+	BallisticCoefficient float64   // C_D A / m [m2/kg]
+
+	Decays   bool      // Whether the nominal re-entry is within the horizon
+	Reentry  time.Time // Nominal re-entry time
+	Earliest time.Time // Re-entry with the density raised by the uncertainty
+	Latest   time.Time // Re-entry with the density lowered by the uncertainty, zero beyond the horizon
+}
+
+// Within reports whether the nominal re-entry is at most d after t
+func (e Estimate) Within(t time.Time, d time.Duration) bool {
+	return e.Decays && !e.Reentry.After(t.Add(d))
+}
+
+// Predict estimates when the satellite of an element set re-enters, from its epoch.
+// The bounds scale the atmospheric density by one plus and one minus the density
+// uncertainty, which covers solar activity and ballistic coefficient errors alike.
+func Predict(sat *model.TleOrbitalElement, opts Options) (Estimate, error) {
+	if opts.DensityUncertainty <= 0 {
+		opts.DensityUncertainty = DefaultDensityUncertainty
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = DefaultHorizon
+	}
+	if opts.Method == MethodNumerical {
+		opts.Horizon = min(opts.Horizon, MaxNumericalHorizon)
+	}
+	if opts.DensityUncertainty >= 1 {
+		return Estimate{}, errors.New("density uncertainty must be below 1")
+	}
+
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		return Estimate{}, err
+	}
+	a := p.SemiMajorAxis()
+	estimate := Estimate{
+		Epoch:    p.Epoch(),
+		Method:   opts.Method,
+		Altitude: a - util.WGS84EquatorialRadius,
+	}
+	if estimate.Altitude <= ReentryAltitude {
+		return Estimate{}, fmt.Errorf("mean altitude %.1f km is below the re-entry altitude", estimate.Altitude)
+	}
+
+	estimate.BallisticCoefficient = opts.BallisticCoefficient
+	if estimate.BallisticCoefficient <= 0 {
+		if estimate.BallisticCoefficient, err = ballisticCoefficient(sat, a, opts); err != nil {
+			return Estimate{}, err
+		}
+	}
+	estimate.DecayRate = decayRate(a, estimate.BallisticCoefficient, opts.Atmosphere, 1) * 86400
+
+	reentry := func(scale float64) (time.Time, bool) {
+		if opts.Method == MethodNumerical {
+			return propagate(p, estimate.BallisticCoefficient, opts.Atmosphere, scale, opts.Horizon)
+		}
+		return integrate(a, p.Epoch(), estimate.BallisticCoefficient, opts.Atmosphere, scale, opts.Horizon)
+	}
+	estimate.Reentry, estimate.Decays = reentry(1)
+	estimate.Earliest, _ = reentry(1 + opts.DensityUncertainty)
+	if latest, ok := reentry(1 - opts.DensityUncertainty); ok {
+		estimate.Latest = latest
+	}
+	return estimate, nil
+}
+
+// ballisticCoefficient derives C_D A / m [m2/kg] from the element set: from B*, or
+// such that the atmosphere reproduces the decay of the mean motion at the rate given by
+// orbital.MeanMotionRate, as the propagator does
+func ballisticCoefficient(sat *model.TleOrbitalElement, a float64, opts Options) (float64, error) {
+	useBStar := opts.Method == MethodBStar ||
+		(opts.Method == MethodNumerical && orbital.MeanMotionRate(sat) <= 0)
+	if useBStar {
+		if sat.BStar <= 0 {
+			return 0, fmt.Errorf("B* %.4e does not indicate drag", sat.BStar)
+		}
+		return 2 * sat.BStar / bStarDensity, nil
+	}
+
+	return calibrate(a, sat.MeanMotion, orbital.MeanMotionRate(sat), opts.Atmosphere)
+}
+
+// calibrate returns the ballistic coefficient C_D A / m [m2/kg] with which the
+// atmosphere decays a near-circular orbit of semi-major axis a [km] and mean motion
+// [Rev/Day] at the rate of change of the mean motion dn/dt [Rev/Day2]
+func calibrate(a, meanMotion, meanMotionRate float64, atmosphere numerical.Atmosphere) (float64, error) {
+	if meanMotionRate <= 0 {
+		return 0, fmt.Errorf("mean motion derivative %.8f does not indicate decay", meanMotionRate)
+	}
+	density := atmosphere.Density(time.Time{}, vecmath.Vec3{X: a})
+	if density == 0 {
+		return 0, errors.New("orbit is above the atmosphere model")
+	}
+	// da/dt = -2/3 a ndot / n [km/s]
+	rate := 2.0 / 3.0 * a * meanMotionRate / meanMotion / 86400
+	return rate / (density * 1000 * math.Sqrt(orbital.EarthMu*a)), nil
+}
+
+// BStar returns the B* drag term [1/EarthRadii] of the ballistic coefficient with which
+// the atmosphere decays a near-circular orbit of mean motion [Rev/Day] at the rate of
+// change of the mean motion dn/dt [Rev/Day2], such as a fitted one
+func BStar(meanMotion, meanMotionRate float64, atmosphere numerical.SolarFlux) (float64, error) {
+	a := orbital.SemiMajorAxis(&model.TleOrbitalElement{MeanMotion: meanMotion})
+	ballistic, err := calibrate(a, meanMotion, meanMotionRate, atmosphere)
+	if err != nil {
+		return 0, err
+	}
+	return bStarDensity * ballistic / 2, nil
+}
+
+// decayRate returns the semi-major axis decay rate of a circular orbit under drag,
+// da/dt = -rho B sqrt(mu a) [km/s], with the density scaled by scale
+func decayRate(a, ballistic float64, atmosphere numerical.Atmosphere, scale float64) float64 {
+	density := scale * atmosphere.Density(time.Time{}, vecmath.Vec3{X: a})
+	// rho [kg/m3] * B [m2/kg] is per meter; 1000 converts it to per km
+	return -density * ballistic * 1000 * math.Sqrt(orbital.EarthMu*a)
+}
+
+// integrate integrates the semi-major axis decay by the Runge-Kutta method from a at
+// start until the re-entry altitude, with steps that keep the change per step small,
+// and returns the re-entry time and whether it is within the horizon
+func integrate(a float64, start time.Time, ballistic float64, atmosphere numerical.Atmosphere, scale float64,
+	horizon time.Duration) (time.Time, bool) {
+	reentryAxis := util.WGS84EquatorialRadius + ReentryAltitude
+	rate := func(a float64) float64 { return decayRate(a, ballistic, atmosphere, scale) }
+
+	elapsed := 0.0
+	for elapsed < horizon.Seconds() {
+		k1 := rate(a)
+		if k1 == 0 {
+			return time.Time{}, false // Above the atmosphere
+		}
+		h := math.Max(minTimeStep, math.Min(maxTimeStep, maxDecayStep/math.Abs(k1)))
+		if a+h*k1 <= reentryAxis {
+			// Decaying so fast that the step would pass the re-entry altitude
+			elapsed += (a - reentryAxis) / -k1
+			return start.Add(time.Duration(elapsed * float64(time.Second))), elapsed <= horizon.Seconds()
+		}
+		k2 := rate(a + h/2*k1)
+		k3 := rate(a + h/2*k2)
+		k4 := rate(a + h*k3)
+		next := a + h/6*(k1+2*k2+2*k3+k4)
+
+		if !(next > reentryAxis) {
+			// Interpolate the crossing within the last step, with the initial rate when
+			// the density rises so steeply that the stages overshoot
+			if math.IsNaN(next) || next >= a {
+				elapsed += (a - reentryAxis) / -k1
+			} else {
+				elapsed += h * (a - reentryAxis) / (a - next)
+			}
+			return start.Add(time.Duration(elapsed * float64(time.Second))), elapsed <= horizon.Seconds()
+		}
+		a = next
+		elapsed += h
+	}
+	return time.Time{}, false
+}
+
+// scaledAtmosphere scales the density of an atmosphere
+type scaledAtmosphere struct {
+	atmosphere numerical.Atmosphere
+	scale      float64
+}
+
+// Density implements numerical.Atmosphere
+func (s scaledAtmosphere) Density(t time.Time, position vecmath.Vec3) float64 {
+	return s.scale * s.atmosphere.Density(t, position)
+}
+
+// propagate propagates the orbit numerically under J2 and drag, one day at a time,
+// and returns the re-entry time and whether it is within the horizon
+func propagate(p *orbital.Propagator, ballistic float64, atmosphere numerical.Atmosphere, scale float64,
+	horizon time.Duration) (time.Time, bool) {
+	field, _ := numerical.ZonalField(2)
+	forces := []numerical.Force{
+		numerical.NewGravity(field),
+		numerical.Drag{
+			Atmosphere: scaledAtmosphere{atmosphere: atmosphere, scale: scale},
+			Spacecraft: numerical.Spacecraft{
+				AreaToMass:      ballistic / numerical.DefaultDragCoefficient,
+				DragCoefficient: numerical.DefaultDragCoefficient,
+			},
+		},
+	}
+	integrator := numerical.NewPropagator(p.StateECI(p.Epoch()), forces, numerical.Options{Tolerance: numericalTolerance})
+
+	end := p.Epoch().Add(horizon)
+	for t := p.Epoch(); t.Before(end); {
+		t = t.Add(24 * time.Hour)
+		if t.After(end) {
+			t = end
+		}
+		state := integrator.StateECI(t)
+		if err := integrator.Err(); err != nil {
+			return state.Time, errors.Is(err, numerical.ErrReentry)
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,101 @@
+package doppler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+)
+
+// SpeedOfLight is the speed of light in vacuum [km/s]
+const SpeedOfLight = 299792.458
+
+// DownlinkFrequency returns the frequency received on the ground for a carrier
+// transmitted by the satellite [Hz], given the range rate (positive when receding) [km/s]
+func DownlinkFrequency(carrier, rangeRate float64) float64 {
+	return carrier * (1 - rangeRate/SpeedOfLight)
+}
+
+// UplinkFrequency returns the frequency to transmit from the ground so that the
+// satellite receives the carrier [Hz], given the range rate (positive when receding) [km/s]
+func UplinkFrequency(carrier, rangeRate float64) float64 {
+	return carrier / (1 - rangeRate/SpeedOfLight)
+}
+
+// Link holds the nominal carrier frequencies of a radio link
+type Link struct {
+	Downlink float64 // Satellite to ground carrier [Hz], zero when unused
+	Uplink   float64 // Ground to satellite carrier [Hz], zero when unused
+}
+
+// Sample is one row of a Doppler tuning table
+type Sample struct {
+	Time           time.Time // Sample time (UTC)
+	Azimuth        float64   // Azimuth [degree]
+	Elevation      float64   // Elevation [degree]
+	RangeRate      float64   // Slant range rate, positive when receding [km/s]
+	DownlinkOffset float64   // Received minus nominal downlink frequency [Hz]
+	UplinkOffset   float64   // Transmit minus nominal uplink frequency [Hz]
+}
+
+// At computes the Doppler sample of the satellite seen from the station at targetTime
+func At(p *orbital.Propagator, station *observer.Station, link Link, targetTime time.Time) Sample {
+	look := station.Look(p, targetTime)
+	sample := Sample{
+		Time:      look.Time,
+		Azimuth:   look.Azimuth,
+		Elevation: look.Elevation,
+		RangeRate: look.RangeRate,
+	}
+	if link.Downlink != 0 {
+		sample.DownlinkOffset = DownlinkFrequency(link.Downlink, look.RangeRate) - link.Downlink
+	}
+	if link.Uplink != 0 {
+		sample.UplinkOffset = UplinkFrequency(link.Uplink, look.RangeRate) - link.Uplink
+	}
+	return sample
+}
+
+// Curve samples the Doppler shift every step from start to stop. The last sample is
+// at stop even when the window is not a whole number of steps.
+func Curve(p *orbital.Propagator, station *observer.Station, link Link, start, stop time.Time, step time.Duration) ([]Sample, error) {
+	if step <= 0 {
+		return nil, errors.New("step must be positive")
+	}
+	if stop.Before(start) {
+		return nil, errors.New("stop time is before start time")
+	}
+
+	samples := make([]Sample, 0, int(stop.Sub(start)/step)+2)
+	for t := start; t.Before(stop); t = t.Add(step) {
+		samples = append(samples, At(p, station, link, t))
+	}
+	return append(samples, At(p, station, link, stop)), nil
+}
+
+// ParseFrequency parses a frequency in Hz, optionally with a kHz, MHz or GHz suffix,
+// e.g. "437.5MHz" or "11.7e9"
+func ParseFrequency(value string) (float64, error) {
+	multipliers := []struct {
+		suffix string
+		factor float64
+	}{{"ghz", 1e9}, {"mhz", 1e6}, {"khz", 1e3}, {"hz", 1}}
+
+	number, factor := strings.ToLower(strings.TrimSpace(value)), 1.0
+	for _, m := range multipliers {
+		if trimmed, ok := strings.CutSuffix(number, m.suffix); ok {
+			number, factor = strings.TrimSpace(trimmed), m.factor
+			break
+		}
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid frequency %q", value)
+	}
+	return f * factor, nil
+}
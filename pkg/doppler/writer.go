@@ -0,0 +1,56 @@
+package doppler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVWriter writes Doppler tuning tables as CSV rows. Columns for an unused
+// direction of the link are omitted.
+type CSVWriter struct {
+	w           *bufio.Writer
+	link        Link
+	wroteHeader bool
+}
+
+// NewCSVWriter creates a CSV writer for tuning tables of the given link
+func NewCSVWriter(w io.Writer, link Link) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriter(w), link: link}
+}
+
+// Write writes a sample of a pass, preceded by the header on the first call. The pass
+// number distinguishes the tables of successive passes of a satellite.
+func (cw *CSVWriter) Write(satellite string, pass int, s Sample) error {
+	if !cw.wroteHeader {
+		header := "satellite,pass,time,azimuth_deg,elevation_deg,range_rate_km_s"
+		if cw.link.Downlink != 0 {
+			header += ",downlink_hz,downlink_offset_hz"
+		}
+		if cw.link.Uplink != 0 {
+			header += ",uplink_hz,uplink_offset_hz"
+		}
+		if _, err := cw.w.WriteString(header + "\n"); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+
+	row := fmt.Sprintf("%s,%d,%s,%.3f,%.3f,%.6f", satellite, pass, s.Time.Format(time.RFC3339Nano),
+		s.Azimuth, s.Elevation, s.RangeRate)
+	if cw.link.Downlink != 0 {
+		row += fmt.Sprintf(",%.1f,%.1f", cw.link.Downlink+s.DownlinkOffset, s.DownlinkOffset)
+	}
+	if cw.link.Uplink != 0 {
+		row += fmt.Sprintf(",%.1f,%.1f", cw.link.Uplink+s.UplinkOffset, s.UplinkOffset)
+	}
+
+	_, err := cw.w.WriteString(row + "\n")
+	return err
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (cw *CSVWriter) Flush() error {
+	return cw.w.Flush()
+}
@@ -0,0 +1,145 @@
+package eclipse
+
+import (
+	"errors"
+	"time"
+
+	"starlink/pkg/orbital"
+)
+
+// DefaultStep is the coarse sampling interval used to bracket shadow boundaries.
+// LEO eclipses last tens of minutes, so a minute does not skip any of them.
+const DefaultStep = time.Minute
+
+// Tolerance is the precision to which entry and exit times are refined
+const Tolerance = time.Millisecond
+
+// Interval is one passage of a satellite through Earth's shadow
+type Interval struct {
+	Start          time.Time // Shadow entry (penumbra entry for the conical model)
+	Stop           time.Time // Shadow exit (penumbra exit for the conical model)
+	UmbraStart     time.Time // Umbra entry, zero if the satellite never reaches umbra
+	UmbraStop      time.Time // Umbra exit, zero if the satellite never reaches umbra
+	StartTruncated bool      // Already in shadow at the window start; Start is the window start
+	EndTruncated   bool      // Still in shadow at the window stop; Stop is the window stop
+}
+
+// Duration returns the total time spent in shadow
+func (i Interval) Duration() time.Duration {
+	return i.Stop.Sub(i.Start)
+}
+
+// UmbraDuration returns the time spent in umbra
+func (i Interval) UmbraDuration() time.Duration {
+	return i.UmbraStop.Sub(i.UmbraStart)
+}
+
+// Options controls the eclipse search
+type Options struct {
+	Start time.Time           // Window start
+	Stop  time.Time           // Window stop
+	Model orbital.ShadowModel // Shadow geometry
+	Step  time.Duration       // Coarse sampling interval, DefaultStep when zero
+}
+
+// span is a time range in which a boundary function is negative
+type span struct {
+	start, stop                  time.Time
+	startTruncated, endTruncated bool
+}
+
+// FindEclipses returns every shadow interval of the satellite within the window, with
+// entry and exit times located by bisection to within Tolerance
+func FindEclipses(p *orbital.Propagator, opts Options) ([]Interval, error) {
+	if opts.Stop.Before(opts.Start) {
+		return nil, errors.New("stop time is before start time")
+	}
+	if opts.Step <= 0 {
+		opts.Step = DefaultStep
+	}
+
+	penumbra := func(t time.Time) float64 {
+		outer, _ := orbital.ShadowBoundaries(p.PositionECI(t), orbital.SunPosition(t), opts.Model)
+		return outer
+	}
+	umbra := func(t time.Time) float64 {
+		_, inner := orbital.ShadowBoundaries(p.PositionECI(t), orbital.SunPosition(t), opts.Model)
+		return inner
+	}
+
+	shadows := findSpans(penumbra, opts)
+	umbras := findSpans(umbra, opts)
+
+	// Umbra spans always lie inside a shadow span
+	intervals := make([]Interval, 0, len(shadows))
+	for _, s := range shadows {
+		interval := Interval{
+			Start:          s.start,
+			Stop:           s.stop,
+			StartTruncated: s.startTruncated,
+			EndTruncated:   s.endTruncated,
+		}
+		for _, u := range umbras {
+			if !u.start.Before(s.start) && !u.stop.After(s.stop) {
+				interval.UmbraStart, interval.UmbraStop = u.start, u.stop
+				break
+			}
+		}
+		intervals = append(intervals, interval)
+	}
+
+	return intervals, nil
+}
+
+// findSpans scans the window for ranges in which fn is negative
+func findSpans(fn func(time.Time) float64, opts Options) []span {
+	var spans []span
+	var current span
+	inside := false
+
+	t0 := opts.Start
+	f0 := fn(t0)
+	if f0 < 0 {
+		current, inside = span{start: t0, startTruncated: true}, true
+	}
+
+	for t0.Before(opts.Stop) {
+		t1 := t0.Add(opts.Step)
+		if t1.After(opts.Stop) {
+			t1 = opts.Stop
+		}
+		f1 := fn(t1)
+
+		switch {
+		case f0 >= 0 && f1 < 0:
+			current, inside = span{start: crossing(fn, t0, t1)}, true
+		case f0 < 0 && f1 >= 0:
+			current.stop = crossing(fn, t0, t1)
+			spans = append(spans, current)
+			inside = false
+		}
+
+		t0, f0 = t1, f1
+	}
+
+	if inside {
+		current.stop, current.endTruncated = opts.Stop, true
+		spans = append(spans, current)
+	}
+
+	return spans
+}
+
+// crossing finds the time in [t0, t1] at which fn changes sign
+func crossing(fn func(time.Time) float64, t0, t1 time.Time) time.Time {
+	entering := fn(t0) >= 0
+	for t1.Sub(t0) > Tolerance {
+		mid := t0.Add(t1.Sub(t0) / 2)
+		if (fn(mid) < 0) == entering {
+			t1 = mid
+		} else {
+			t0 = mid
+		}
+	}
+	return t0.Add(t1.Sub(t0) / 2)
+}
@@ -0,0 +1,167 @@
+package ephemeris
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+)
+
+// Frame selects the reference frame of generated ephemeris records
+type Frame int
+
+const (
+	// FrameECI is the equatorial (Earth-centred inertial) frame used by the propagator
+	FrameECI Frame = iota
+	// FrameECEF is the Earth-fixed frame
+	FrameECEF
+	// FrameGeodetic is latitude/longitude/altitude with Earth-fixed velocity
+	FrameGeodetic
+)
+
+// String returns the name of the frame as accepted by ParseFrame
+func (f Frame) String() string {
+	switch f {
+	case FrameECI:
+		return "eci"
+	case FrameECEF:
+		return "ecef"
+	case FrameGeodetic:
+		return "geodetic"
+	default:
+		return fmt.Sprintf("Frame(%d)", int(f))
+	}
+}
+
+// ParseFrame parses a frame name (eci, ecef or geodetic)
+func ParseFrame(name string) (Frame, error) {
+	switch strings.ToLower(name) {
+	case "eci":
+		return FrameECI, nil
+	case "ecef":
+		return FrameECEF, nil
+	case "geodetic", "lla":
+		return FrameGeodetic, nil
+	default:
+		return 0, fmt.Errorf("unknown frame %q (expected eci, ecef or geodetic)", name)
+	}
+}
+
+// Window describes the time span and sampling of an ephemeris
+type Window struct {
+	Start time.Time     // First sample time
+	Stop  time.Time     // Last sample time (inclusive)
+	Step  time.Duration // Sampling interval, used when Count is zero
+	Count int           // Number of evenly spaced samples from Start to Stop, optional
+}
+
+// Validate checks that the window describes at least one sample
+func (w Window) Validate() error {
+	if w.Stop.Before(w.Start) {
+		return errors.New("stop time is before start time")
+	}
+	if w.Count < 0 {
+		return errors.New("sample count must not be negative")
+	}
+	if w.Count == 0 && w.Step <= 0 {
+		return errors.New("step must be positive when no sample count is given")
+	}
+	return nil
+}
+
+// Len returns the number of samples in the window
+func (w Window) Len() int {
+	if w.Count > 0 {
+		return w.Count
+	}
+	return int(w.Stop.Sub(w.Start)/w.Step) + 1
+}
+
+// At returns the time of the i-th sample
+func (w Window) At(i int) time.Time {
+	if w.Count > 0 {
+		if w.Count == 1 {
+			return w.Start
+		}
+		span := w.Stop.Sub(w.Start)
+		return w.Start.Add(time.Duration(float64(span) * float64(i) / float64(w.Count-1)))
+	}
+	return w.Start.Add(time.Duration(i) * w.Step)
+}
+
+// Record is a single ephemeris row for one satellite at one time
+type Record struct {
+	Satellite string            // Satellite name
+	Frame     Frame             // Frame of State
+	State     model.StateVector // Position and velocity (Earth-fixed for FrameGeodetic)
+	Lat       float64           // Latitude [degree], FrameGeodetic only
+	Lng       float64           // Longitude [degree], FrameGeodetic only
+	Alt       float64           // Altitude [km], FrameGeodetic only
+}
+
+// Propagator computes the equatorial states of one satellite. It is implemented by
+// the analytic propagator of package orbital and the numerical propagator.
+type Propagator interface {
+	StateECI(targetTime time.Time) model.StateVector
+}
+
+// Generate propagates each satellite over the window with the analytic propagator and
+// passes every record to emit as soon as it is computed, so memory use does not grow
+// with the window. Records are emitted satellite by satellite in time order; an error
+// returned by emit stops the generation and is returned.
+func Generate(satellites []model.Satellite, window Window, frame Frame, emit func(Record) error) error {
+	analytic := func(sat model.Satellite) (Propagator, error) {
+		return orbital.NewPropagator(sat.Elements)
+	}
+	return GenerateWith(satellites, window, frame, analytic, emit)
+}
+
+// GenerateWith is Generate with the propagator of each satellite built by newPropagator.
+// Propagators that report integration failures through an Err method stop the
+// generation with that error.
+func GenerateWith(satellites []model.Satellite, window Window, frame Frame,
+	newPropagator func(model.Satellite) (Propagator, error), emit func(Record) error) error {
+	if err := window.Validate(); err != nil {
+		return err
+	}
+
+	samples := window.Len()
+	for _, sat := range satellites {
+		propagator, err := newPropagator(sat)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sat.Name, err)
+		}
+		failing, _ := propagator.(interface{ Err() error })
+		for i := 0; i < samples; i++ {
+			record := NewRecord(sat.Name, propagator, window.At(i), frame)
+			if failing != nil && failing.Err() != nil {
+				return fmt.Errorf("%s: %w", sat.Name, failing.Err())
+			}
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewRecord computes a single ephemeris record for a satellite
+func NewRecord(name string, propagator Propagator, t time.Time, frame Frame) Record {
+	record := Record{Satellite: name, Frame: frame}
+
+	record.State = propagator.StateECI(t)
+	switch frame {
+	case FrameECEF:
+		record.State = *orbital.EarthFixedState(&record.State)
+	case FrameGeodetic:
+		record.State = *orbital.EarthFixedState(&record.State)
+		record.Lat, record.Lng, record.Alt = orbital.EarthFixedToGeodetic(
+			record.State.X, record.State.Y, record.State.Z)
+	}
+
+	return record
+}
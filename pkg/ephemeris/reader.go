@@ -0,0 +1,76 @@
+package ephemeris
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadCSV reads equatorial ephemeris records from a CSV file
+func LoadCSV(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ephemeris: %w", err)
+	}
+	defer file.Close()
+
+	return ParseCSV(file)
+}
+
+// ParseCSV parses equatorial ephemeris records in the format written by CSVWriter:
+// satellite, RFC 3339 time, position [km] and velocity [km/s]. Blank lines, lines
+// starting with # and the header row are ignored; geodetic ephemerides are rejected.
+func ParseCSV(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("ephemeris line %d: expected 8 columns, got %d", lineNo, len(fields))
+		}
+		if strings.TrimSpace(fields[1]) == "time" {
+			if strings.TrimSpace(fields[2]) == "lat_deg" {
+				return nil, fmt.Errorf("ephemeris line %d: geodetic ephemerides are not supported", lineNo)
+			}
+			continue // Header row
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("ephemeris line %d: invalid time: %w", lineNo, err)
+		}
+		var values [6]float64
+		for i := range values {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(fields[i+2]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("ephemeris line %d: invalid number in column %d", lineNo, i+3)
+			}
+		}
+
+		record := Record{Satellite: strings.TrimSpace(fields[0]), Frame: FrameECI}
+		record.State.Time = t.UTC()
+		record.State.X, record.State.Y, record.State.Z = values[0], values[1], values[2]
+		record.State.VX, record.State.VY, record.State.VZ = values[3], values[4], values[5]
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("ephemeris has no records")
+	}
+
+	return records, nil
+}
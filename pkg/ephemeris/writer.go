@@ -0,0 +1,49 @@
+package ephemeris
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVWriter writes ephemeris records as CSV rows
+type CSVWriter struct {
+	w           *bufio.Writer
+	frame       Frame
+	wroteHeader bool
+}
+
+// NewCSVWriter creates a CSV writer for records in the given frame
+func NewCSVWriter(w io.Writer, frame Frame) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriter(w), frame: frame}
+}
+
+// Write writes a record, preceded by the header on the first call
+func (cw *CSVWriter) Write(r Record) error {
+	if !cw.wroteHeader {
+		header := "satellite,time,x_km,y_km,z_km,vx_km_s,vy_km_s,vz_km_s\n"
+		if cw.frame == FrameGeodetic {
+			header = "satellite,time,lat_deg,lng_deg,alt_km,vx_km_s,vy_km_s,vz_km_s\n"
+		}
+		if _, err := cw.w.WriteString(header); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+
+	x, y, z := r.State.X, r.State.Y, r.State.Z
+	if cw.frame == FrameGeodetic {
+		x, y, z = r.Lat, r.Lng, r.Alt
+	}
+
+	_, err := fmt.Fprintf(cw.w, "%s,%s,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f\n",
+		r.Satellite, r.State.Time.Format(time.RFC3339Nano),
+		x, y, z, r.State.VX, r.State.VY, r.State.VZ)
+	return err
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (cw *CSVWriter) Flush() error {
+	return cw.w.Flush()
+}
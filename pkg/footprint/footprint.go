@@ -0,0 +1,136 @@
+package footprint
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// DefaultMinElevation is the elevation mask of Starlink user terminals [degree]
+const DefaultMinElevation = 25.0
+
+// DefaultPoints is the number of boundary points of a footprint polygon
+const DefaultPoints = 72
+
+// MeanEarthRadius is the radius used to express footprint sizes as ground distances [km]
+const MeanEarthRadius = 6371.0088
+
+// LatLng is a point on the ground
+type LatLng struct {
+	Lat float64 // Latitude [degree]
+	Lng float64 // Longitude [degree], in [-180, 180)
+}
+
+// Footprint is the area on the ground from which a satellite is seen above a
+// minimum elevation
+type Footprint struct {
+	Satellite    string    // Satellite name
+	Time         time.Time // Evaluation time (UTC)
+	Center       LatLng    // Sub-satellite point
+	MinElevation float64   // Elevation mask [degree]
+	Radius       float64   // Mean ground distance from the centre to the boundary [km]
+	Ring         []LatLng  // Boundary in clockwise order seen from above, not closed, longitudes wrapped
+}
+
+// Options controls the footprint computation
+type Options struct {
+	MinElevation float64 // Elevation mask [degree], in [0, 90)
+	Points       int     // Number of boundary points, DefaultPoints when zero
+}
+
+// Compute returns the footprint of the satellite at targetTime
+func Compute(name string, p *orbital.Propagator, targetTime time.Time, opts Options) (Footprint, error) {
+	state := p.StateECEF(targetTime)
+	footprint, err := FromPosition(vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}, opts)
+	footprint.Satellite, footprint.Time = name, state.Time
+	return footprint, err
+}
+
+// FromPosition returns the footprint of a satellite at an Earth-fixed position [km].
+// For each azimuth from the sub-satellite point, the boundary is the ground point on the
+// WGS-84 ellipsoid at which the satellite appears at the minimum elevation, located by
+// bisection along the great circle.
+func FromPosition(position vecmath.Vec3, opts Options) (Footprint, error) {
+	if opts.MinElevation < 0 || opts.MinElevation >= 90 {
+		return Footprint{}, errors.New("minimum elevation must be in [0, 90) degrees")
+	}
+	if opts.Points <= 0 {
+		opts.Points = DefaultPoints
+	}
+
+	lat, lng, _ := orbital.EarthFixedToGeodetic(position.X, position.Y, position.Z)
+	center := LatLng{Lat: lat, Lng: normalizeLongitude(lng)}
+
+	// The boundary lies within the geometric horizon of the smallest Earth radius
+	polarRadius := util.WGS84EquatorialRadius * (1 - util.WGS84Flattening)
+	r := position.Norm()
+	if r <= polarRadius {
+		return Footprint{}, errors.New("satellite is below the Earth's surface")
+	}
+	maxAngle := math.Acos(polarRadius / r)
+
+	footprint := Footprint{Center: center, MinElevation: opts.MinElevation}
+	total := 0.0
+	for i := 0; i < opts.Points; i++ {
+		azimuth := 360.0 * float64(i) / float64(opts.Points)
+		angle := boundaryAngle(position, center, azimuth, opts.MinElevation, maxAngle)
+		footprint.Ring = append(footprint.Ring, destination(center, azimuth, angle))
+		total += angle
+	}
+	footprint.Radius = total / float64(opts.Points) * MeanEarthRadius
+
+	return footprint, nil
+}
+
+// boundaryAngle finds the angular distance [Rad] from the centre along an azimuth at
+// which the satellite's elevation falls to minElevation
+func boundaryAngle(position vecmath.Vec3, center LatLng, azimuth, minElevation, maxAngle float64) float64 {
+	elevation := func(angle float64) float64 {
+		ground := destination(center, azimuth, angle)
+		station := observer.NewStation(model.Observer{Lat: ground.Lat, Lng: ground.Lng})
+		return station.LookAt(model.StateVector{X: position.X, Y: position.Y, Z: position.Z}).Elevation
+	}
+
+	lo, hi := 0.0, maxAngle
+	for hi-lo > 1e-9 {
+		mid := (lo + hi) / 2
+		if elevation(mid) > minElevation {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// destination returns the point at an angular distance [Rad] from start along the
+// great circle with the given initial azimuth [degree]
+func destination(start LatLng, azimuth, angle float64) LatLng {
+	sinLat1, cosLat1 := math.Sincos(util.Deg2Rad(start.Lat))
+	sinAz, cosAz := math.Sincos(util.Deg2Rad(azimuth))
+	sinD, cosD := math.Sincos(angle)
+
+	sinLat2 := sinLat1*cosD + cosLat1*sinD*cosAz
+	lat2 := math.Asin(math.Max(-1, math.Min(1, sinLat2)))
+	dLng := math.Atan2(sinAz*sinD*cosLat1, cosD-sinLat1*sinLat2)
+
+	return LatLng{
+		Lat: util.Rad2Deg(lat2),
+		Lng: normalizeLongitude(start.Lng + util.Rad2Deg(dLng)),
+	}
+}
+
+// normalizeLongitude wraps a longitude into [-180, 180) [degree]
+func normalizeLongitude(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
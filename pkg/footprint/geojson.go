@@ -0,0 +1,67 @@
+package footprint
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// geoJSONFeature is a GeoJSON feature with a multi-polygon geometry
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Properties geoJSONProps    `json:"properties"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+}
+
+// geoJSONProps are the properties of a footprint feature
+type geoJSONProps struct {
+	Satellite    string  `json:"satellite"`
+	Time         string  `json:"time"`
+	CenterLat    float64 `json:"center_lat_deg"`
+	CenterLng    float64 `json:"center_lng_deg"`
+	MinElevation float64 `json:"min_elevation_deg"`
+	Radius       float64 `json:"radius_km"`
+}
+
+// geoJSONGeometry is a GeoJSON MultiPolygon
+type geoJSONGeometry struct {
+	Type        string           `json:"type"`
+	Coordinates [][][][2]float64 `json:"coordinates"`
+}
+
+// WriteGeoJSON writes footprints as a GeoJSON FeatureCollection of MultiPolygon
+// features (RFC 7946: split at the antimeridian, counter-clockwise rings)
+func WriteGeoJSON(w io.Writer, footprints []Footprint) error {
+	collection := struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	for _, fp := range footprints {
+		geometry := geoJSONGeometry{Type: "MultiPolygon"}
+		for _, ring := range fp.Polygons() {
+			coordinates := make([][2]float64, len(ring))
+			for i, point := range ring {
+				coordinates[i] = [2]float64{point.Lng, point.Lat}
+			}
+			geometry.Coordinates = append(geometry.Coordinates, [][][2]float64{coordinates})
+		}
+
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type: "Feature",
+			Properties: geoJSONProps{
+				Satellite:    fp.Satellite,
+				Time:         fp.Time.Format(time.RFC3339),
+				CenterLat:    fp.Center.Lat,
+				CenterLng:    fp.Center.Lng,
+				MinElevation: fp.MinElevation,
+				Radius:       fp.Radius,
+			},
+			Geometry: geometry,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
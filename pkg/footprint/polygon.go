@@ -0,0 +1,145 @@
+package footprint
+
+import "math"
+
+// Polygons returns the footprint as closed, counter-clockwise rings with longitudes in
+// [-180, 180], ready for map exporters. A footprint that crosses the antimeridian is
+// split into one ring on each side. A footprint that contains a pole becomes a single
+// ring spanning all longitudes that runs along the antimeridian to the pole.
+func (f Footprint) Polygons() [][]LatLng {
+	if len(f.Ring) < 3 {
+		return nil
+	}
+
+	// Counter-clockwise with continuous (unwrapped) longitudes
+	ring := make([]LatLng, len(f.Ring))
+	for i, point := range f.Ring {
+		ring[len(ring)-1-i] = point
+	}
+	for i := 1; i < len(ring); i++ {
+		ring[i].Lng = unwrap(ring[i].Lng, ring[i-1].Lng)
+	}
+	closing := unwrap(ring[0].Lng, ring[len(ring)-1].Lng)
+
+	if winding := closing - ring[0].Lng; math.Abs(winding) > 180 {
+		return [][]LatLng{polarRing(ring, winding)}
+	}
+
+	// Shift so that the ring starts inside [-180, 180), then clip it at the antimeridian
+	shift := ring[0].Lng - normalizeLongitude(ring[0].Lng)
+	for i := range ring {
+		ring[i].Lng -= shift
+	}
+
+	var polygons [][]LatLng
+	for _, offset := range []float64{-360, 0, 360} {
+		part := clip(ring, offset-180, offset+180)
+		if len(part) < 3 {
+			continue
+		}
+		for i := range part {
+			part[i].Lng -= offset
+		}
+		polygons = append(polygons, closeRing(part))
+	}
+	return polygons
+}
+
+// polarRing turns a ring that winds once around a pole into a polygon in [-180, 180]
+// by cutting it at the antimeridian and closing it through the pole. A positive
+// winding (eastward, counter-clockwise seen from the north) encloses the north pole.
+func polarRing(ring []LatLng, winding float64) []LatLng {
+	// Repeat the ring over two turns so that a full turn starting at -180 can be cut out
+	n := len(ring)
+	extended := make([]LatLng, 0, 2*n+1)
+	for turn := 0; turn < 2; turn++ {
+		for _, point := range ring {
+			extended = append(extended, LatLng{Lat: point.Lat, Lng: point.Lng + float64(turn)*winding})
+		}
+	}
+	extended = append(extended, LatLng{Lat: ring[0].Lat, Lng: ring[0].Lng + 2*winding})
+
+	// Bring the extended ring to start within [-540, -180) for eastward windings or
+	// (180, 540] for westward ones, so that one full turn crosses [-180, 180]
+	from, to := -180.0, 180.0
+	if winding < 0 {
+		from, to = 180.0, -180.0
+	}
+	shift := math.Floor((extended[0].Lng-from)/360)*360 + 360
+	if winding < 0 {
+		shift = math.Ceil((extended[0].Lng-from)/360)*360 - 360
+	}
+	for i := range extended {
+		extended[i].Lng -= shift
+	}
+
+	var result []LatLng
+	for i := 1; i < len(extended); i++ {
+		a, b := extended[i-1], extended[i]
+		if between(from, a.Lng, b.Lng) {
+			result = append(result, interpolate(a, b, from))
+		}
+		if len(result) > 0 {
+			if between(to, a.Lng, b.Lng) {
+				result = append(result, interpolate(a, b, to))
+				break
+			}
+			result = append(result, b)
+		}
+	}
+
+	pole := 90.0
+	if winding < 0 {
+		pole = -90.0
+	}
+	result = append(result, LatLng{Lat: pole, Lng: to}, LatLng{Lat: pole, Lng: from})
+	return closeRing(result)
+}
+
+// clip keeps the part of a ring with longitudes in [west, east]
+// (Sutherland-Hodgman against the two meridians)
+func clip(ring []LatLng, west, east float64) []LatLng {
+	inside := func(p LatLng) bool { return p.Lng >= west }
+	ring = clipEdge(ring, inside, west)
+	inside = func(p LatLng) bool { return p.Lng <= east }
+	return clipEdge(ring, inside, east)
+}
+
+// clipEdge clips a ring against one meridian
+func clipEdge(ring []LatLng, inside func(LatLng) bool, lng float64) []LatLng {
+	var result []LatLng
+	for i := range ring {
+		current, next := ring[i], ring[(i+1)%len(ring)]
+		if inside(current) {
+			result = append(result, current)
+		}
+		if inside(current) != inside(next) {
+			result = append(result, interpolate(current, next, lng))
+		}
+	}
+	return result
+}
+
+// interpolate returns the point on the segment from a to b at longitude lng
+func interpolate(a, b LatLng, lng float64) LatLng {
+	if a.Lng == b.Lng {
+		return LatLng{Lat: a.Lat, Lng: lng}
+	}
+	f := (lng - a.Lng) / (b.Lng - a.Lng)
+	return LatLng{Lat: a.Lat + f*(b.Lat-a.Lat), Lng: lng}
+}
+
+// between reports whether x lies between a and b, including b but not a
+func between(x, a, b float64) bool {
+	return (a < x && x <= b) || (b <= x && x < a)
+}
+
+// unwrap returns lng shifted by a multiple of 360 to lie within 180 degrees of ref
+func unwrap(lng, ref float64) float64 {
+	return lng - math.Round((lng-ref)/360)*360
+}
+
+// closeRing appends the first point to the end of a ring
+func closeRing(ring []LatLng) []LatLng {
+	return append(ring, ring[0])
+}
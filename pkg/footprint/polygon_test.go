@@ -0,0 +1,98 @@
+package footprint_test
+
+import (
+	"math"
+	"testing"
+
+	"starlink/pkg/footprint"
+	"starlink/pkg/vecmath"
+)
+
+// footprintAt computes the footprint of a satellite at a height [km] above a point of a
+// spherical Earth, seen above 10 degrees of elevation
+func footprintAt(t *testing.T, lat, lng, height float64) footprint.Footprint {
+	t.Helper()
+	phi, lambda := lat*math.Pi/180, lng*math.Pi/180
+	r := 6378.137 + height
+	position := vecmath.Vec3{X: r * math.Cos(phi) * math.Cos(lambda), Y: r * math.Cos(phi) * math.Sin(lambda), Z: r * math.Sin(phi)}
+	fp, err := footprint.FromPosition(position, footprint.Options{MinElevation: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fp
+}
+
+// checkRing verifies that a ring is closed, counter-clockwise on the map and within
+// [-180, 180] of longitude, and returns its longitude range
+func checkRing(t *testing.T, ring []footprint.LatLng) (west, east float64) {
+	t.Helper()
+	if len(ring) < 4 {
+		t.Fatalf("ring has %d points", len(ring))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		t.Errorf("ring is not closed: %v ... %v", ring[0], ring[len(ring)-1])
+	}
+	west, east = math.Inf(1), math.Inf(-1)
+	area := 0.0
+	for i, p := range ring {
+		west, east = math.Min(west, p.Lng), math.Max(east, p.Lng)
+		if i > 0 {
+			q := ring[i-1]
+			area += q.Lng*p.Lat - p.Lng*q.Lat
+		}
+	}
+	if west < -180 || east > 180 {
+		t.Errorf("longitudes span [%g, %g]", west, east)
+	}
+	if area <= 0 {
+		t.Errorf("ring is clockwise (signed area %g)", area/2)
+	}
+	return west, east
+}
+
+func TestPolygonsSplitAtAntimeridian(t *testing.T) {
+	polygons := footprintAt(t, 10, 179, 550).Polygons()
+	if len(polygons) != 2 {
+		t.Fatalf("%d polygons, want one on each side of the antimeridian", len(polygons))
+	}
+	var sides [2]bool
+	for _, ring := range polygons {
+		west, east := checkRing(t, ring)
+		switch {
+		case west == -180 && east < 0:
+			sides[0] = true
+		case east == 180 && west > 0:
+			sides[1] = true
+		default:
+			t.Errorf("ring spans [%g, %g], want it to end at the antimeridian", west, east)
+		}
+	}
+	if !sides[0] || !sides[1] {
+		t.Errorf("rings do not cover both sides of the antimeridian")
+	}
+}
+
+func TestPolygonsEnclosingPole(t *testing.T) {
+	for _, pole := range []float64{90, -90} {
+		fp := footprintAt(t, pole-2*math.Copysign(1, pole), 30, 1000)
+		polygons := fp.Polygons()
+		if len(polygons) != 1 {
+			t.Fatalf("pole %g: %d polygons, want 1", pole, len(polygons))
+		}
+		ring := polygons[0]
+		if west, east := checkRing(t, ring); west != -180 || east != 180 {
+			t.Errorf("pole %g: ring spans [%g, %g], want all longitudes", pole, west, east)
+		}
+		atPole := 0
+		for _, p := range ring {
+			if p.Lat == pole {
+				atPole++
+			} else if p.Lat*pole < 0 {
+				t.Errorf("pole %g: point %v in the other hemisphere", pole, p)
+			}
+		}
+		if atPole < 2 {
+			t.Errorf("pole %g: ring does not run along the pole", pole)
+		}
+	}
+}
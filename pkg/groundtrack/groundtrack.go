@@ -0,0 +1,145 @@
+package groundtrack
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"starlink/pkg/orbital"
+)
+
+// DefaultStep is the sampling interval of the sub-satellite point. A LEO satellite
+// moves about 2 degrees of arc in 30 seconds, which draws as a smooth curve.
+const DefaultStep = 30 * time.Second
+
+// Tolerance is the precision to which node times are refined
+const Tolerance = time.Millisecond
+
+// Point is a sample of the sub-satellite point
+type Point struct {
+	Time time.Time // Sample time (UTC)
+	Lat  float64   // Latitude [degree]
+	Lng  float64   // Longitude [degree], in [-180, 180]
+	Alt  float64   // Altitude [km]
+}
+
+// Segment is a part of the ground track that does not cross the antimeridian. Segments
+// that end or start at the antimeridian have an interpolated point at exactly +180 or -180.
+type Segment []Point
+
+// Node is an equator crossing of the ground track
+type Node struct {
+	Point
+	Ascending bool // Northbound crossing (ascending node), otherwise descending
+}
+
+// Track is the ground track of one satellite over a window
+type Track struct {
+	Satellite string    // Satellite name
+	Segments  []Segment // Polylines split at the antimeridian, in time order
+	Nodes     []Node    // Equator crossings, only with Options.Nodes
+}
+
+// Options controls ground track generation
+type Options struct {
+	Start  time.Time     // Window start
+	Stop   time.Time     // Window stop, ignored when Orbits is set
+	Orbits float64       // Window length in orbital periods, from the mean motion
+	Step   time.Duration // Sampling interval, DefaultStep when zero
+	Nodes  bool          // Locate ascending and descending nodes
+}
+
+// Generate samples the ground track of the satellite over the window and splits it
+// into segments at the antimeridian
+func Generate(name string, p *orbital.Propagator, opts Options) (Track, error) {
+	track := Track{Satellite: name}
+
+	if opts.Orbits > 0 {
+		period := time.Duration(opts.Orbits * 86400.0 / p.Elements().MeanMotion * float64(time.Second))
+		opts.Stop = opts.Start.Add(period)
+	}
+	if opts.Stop.Before(opts.Start) {
+		return track, errors.New("stop time is before start time")
+	}
+	if opts.Step <= 0 {
+		opts.Step = DefaultStep
+	}
+
+	sample := func(t time.Time) Point {
+		location := p.Location(t)
+		return Point{Time: t.UTC(), Lat: location.Lat, Lng: normalizeLongitude(location.Lng), Alt: location.Alt}
+	}
+
+	prev := sample(opts.Start)
+	current := Segment{prev}
+
+	for t := opts.Start; t.Before(opts.Stop); {
+		t = t.Add(opts.Step)
+		if t.After(opts.Stop) {
+			t = opts.Stop
+		}
+		next := sample(t)
+
+		if opts.Nodes && (prev.Lat < 0) != (next.Lat < 0) {
+			track.Nodes = append(track.Nodes, findNode(prev, next, sample))
+		}
+
+		if math.Abs(next.Lng-prev.Lng) > 180 {
+			// Close the segment on the antimeridian and continue on the other side
+			end, start := splitAntimeridian(prev, next)
+			track.Segments = append(track.Segments, append(current, end))
+			current = Segment{start}
+		}
+		current = append(current, next)
+		prev = next
+	}
+	track.Segments = append(track.Segments, current)
+
+	return track, nil
+}
+
+// splitAntimeridian interpolates the antimeridian crossing between two samples whose
+// longitudes lie on opposite sides of it, returning the point at the end of the first
+// segment and the point at the start of the next
+func splitAntimeridian(a, b Point) (Point, Point) {
+	// Unwrap the second longitude so that the step is continuous
+	lngB, edge := b.Lng+360, 180.0
+	if a.Lng < 0 {
+		lngB, edge = b.Lng-360, -180.0
+	}
+
+	f := (edge - a.Lng) / (lngB - a.Lng)
+	crossing := Point{
+		Time: a.Time.Add(time.Duration(f * float64(b.Time.Sub(a.Time)))),
+		Lat:  a.Lat + f*(b.Lat-a.Lat),
+		Alt:  a.Alt + f*(b.Alt-a.Alt),
+	}
+
+	end, start := crossing, crossing
+	end.Lng, start.Lng = edge, -edge
+	return end, start
+}
+
+// findNode locates the equator crossing between two samples by bisection
+func findNode(a, b Point, sample func(time.Time) Point) Node {
+	ascending := a.Lat < 0
+	t0, t1 := a.Time, b.Time
+	for t1.Sub(t0) > Tolerance {
+		mid := t0.Add(t1.Sub(t0) / 2)
+		if (sample(mid).Lat >= 0) == ascending {
+			t1 = mid
+		} else {
+			t0 = mid
+		}
+	}
+	return Node{Point: sample(t0.Add(t1.Sub(t0) / 2)), Ascending: ascending}
+}
+
+// normalizeLongitude wraps a longitude into [-180, 180) [degree]
+func normalizeLongitude(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
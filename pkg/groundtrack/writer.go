@@ -0,0 +1,60 @@
+package groundtrack
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVWriter writes ground tracks as CSV rows. Track points are numbered by segment;
+// equator crossings follow them with the kind ascending or descending.
+type CSVWriter struct {
+	w           *bufio.Writer
+	wroteHeader bool
+}
+
+// NewCSVWriter creates a CSV writer for ground tracks
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes the points and nodes of a track, preceded by the header on the first call
+func (cw *CSVWriter) Write(track Track) error {
+	if !cw.wroteHeader {
+		if _, err := cw.w.WriteString("satellite,kind,segment,time,lat_deg,lng_deg,alt_km\n"); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+
+	for i, segment := range track.Segments {
+		for _, point := range segment {
+			if err := cw.writeRow(track.Satellite, "point", fmt.Sprint(i+1), point); err != nil {
+				return err
+			}
+		}
+	}
+	for _, node := range track.Nodes {
+		kind := "descending"
+		if node.Ascending {
+			kind = "ascending"
+		}
+		if err := cw.writeRow(track.Satellite, kind, "", node.Point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRow writes a single point
+func (cw *CSVWriter) writeRow(satellite, kind, segment string, p Point) error {
+	_, err := fmt.Fprintf(cw.w, "%s,%s,%s,%s,%.6f,%.6f,%.3f\n",
+		satellite, kind, segment, p.Time.Format(time.RFC3339Nano), p.Lat, p.Lng, p.Alt)
+	return err
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (cw *CSVWriter) Flush() error {
+	return cw.w.Flush()
+}
@@ -0,0 +1,356 @@
+package iod
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// HerrickGibbsLimit is the angle between position vectors [degree] below which
+// Herrick-Gibbs replaces Gibbs, which loses accuracy for closely spaced vectors
+const HerrickGibbsLimit = 5.0
+
+// TrackGap is the longest interval between observations of the same track
+const TrackGap = 10 * time.Minute
+
+// gaussIterations limits the refinement of the Gauss solution with exact Lagrange coefficients
+const gaussIterations = 100
+
+// Observation is a topocentric measurement of a satellite from a ground station
+type Observation struct {
+	Satellite      string            // Name of the observed object, optional
+	Time           time.Time         // Time of the measurement (UTC)
+	Station        *observer.Station // Observing station
+	RightAscension float64           // Topocentric right ascension in the propagator's equatorial frame [degree]
+	Declination    float64           // Topocentric declination [degree]
+	Range          float64           // Slant range [km], zero when not measured
+}
+
+// LineOfSight returns the unit vector from the station towards the satellite
+func (o Observation) LineOfSight() vecmath.Vec3 {
+	sinRa, cosRa := math.Sincos(util.Deg2Rad(o.RightAscension))
+	sinDec, cosDec := math.Sincos(util.Deg2Rad(o.Declination))
+	return vecmath.Vec3{X: cosDec * cosRa, Y: cosDec * sinRa, Z: sinDec}
+}
+
+// Position returns the equatorial position of a ranged observation [km]
+func (o Observation) Position() vecmath.Vec3 {
+	return o.Station.PositionECI(o.Time).Add(o.LineOfSight().Scale(o.Range))
+}
+
+// Method identifies an initial orbit determination method
+type Method int
+
+const (
+	// MethodGauss determines the orbit from three lines of sight
+	MethodGauss Method = iota
+	// MethodGibbs determines the orbit from three widely spaced position vectors
+	MethodGibbs
+	// MethodHerrickGibbs determines the orbit from three closely spaced position vectors
+	MethodHerrickGibbs
+)
+
+// String returns the name of the method
+func (m Method) String() string {
+	switch m {
+	case MethodGauss:
+		return "Gauss"
+	case MethodGibbs:
+		return "Gibbs"
+	case MethodHerrickGibbs:
+		return "Herrick-Gibbs"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// Determine computes an initial orbit from three observations of the first track with
+// at least three: its first, middle and last observation. Ranged observations give
+// position vectors for Gibbs or, when they are close together, Herrick-Gibbs; otherwise
+// the angles are used with Gauss's method. The state is at the time of the middle
+// observation.
+func Determine(observations []Observation) (model.StateVector, Method, error) {
+	track, err := firstTrack(observations)
+	if err != nil {
+		return model.StateVector{}, MethodGauss, err
+	}
+	o1, o2, o3 := track[0], track[len(track)/2], track[len(track)-1]
+
+	if o1.Range > 0 && o2.Range > 0 && o3.Range > 0 {
+		r1, r2, r3 := o1.Position(), o2.Position(), o3.Position()
+		if math.Min(angleBetween(r1, r2), angleBetween(r2, r3)) < HerrickGibbsLimit {
+			state, err := HerrickGibbs(r1, r2, r3, o1.Time, o2.Time, o3.Time)
+			return state, MethodHerrickGibbs, err
+		}
+		state, err := Gibbs(r1, r2, r3, o2.Time)
+		return state, MethodGibbs, err
+	}
+
+	state, err := Gauss(o1, o2, o3)
+	return state, MethodGauss, err
+}
+
+// firstTrack returns the observations, in time order, of the first track with at
+// least three observations; observations belong to the same track when they are less
+// than TrackGap apart
+func firstTrack(observations []Observation) ([]Observation, error) {
+	sorted := append([]Observation(nil), observations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	start := 0
+	for i := 1; i <= len(sorted); i++ {
+		if i == len(sorted) || sorted[i].Time.Sub(sorted[i-1].Time) >= TrackGap {
+			if i-start >= 3 {
+				return sorted[start:i], nil
+			}
+			start = i
+		}
+	}
+	return nil, errors.New("no track with at least three observations")
+}
+
+// Gauss determines the state at the time of the middle of three angles-only
+// observations with Gauss's method, refined by iterating the Lagrange coefficients
+// with the universal-variable solution of Kepler's problem (Curtis, Orbital Mechanics
+// for Engineering Students, Algorithms 5.5 and 5.6). When the eighth-degree equation
+// for the distance has several roots above the Earth's surface the smallest is used.
+func Gauss(o1, o2, o3 Observation) (model.StateVector, error) {
+	mu := orbital.EarthMu
+	tau1 := o1.Time.Sub(o2.Time).Seconds()
+	tau3 := o3.Time.Sub(o2.Time).Seconds()
+	tau := tau3 - tau1
+	if tau1 >= 0 || tau3 <= 0 {
+		return model.StateVector{}, errors.New("observations must be at distinct, increasing times")
+	}
+
+	rho1, rho2, rho3 := o1.LineOfSight(), o2.LineOfSight(), o3.LineOfSight()
+	R1, R2, R3 := o1.Station.PositionECI(o1.Time), o2.Station.PositionECI(o2.Time), o3.Station.PositionECI(o3.Time)
+
+	p1, p2, p3 := rho2.Cross(rho3), rho1.Cross(rho3), rho1.Cross(rho2)
+	d0 := rho1.Dot(p1)
+	if math.Abs(d0) < 1e-12 {
+		return model.StateVector{}, errors.New("lines of sight are coplanar")
+	}
+	d := [3][3]float64{
+		{R1.Dot(p1), R1.Dot(p2), R1.Dot(p3)},
+		{R2.Dot(p1), R2.Dot(p2), R2.Dot(p3)},
+		{R3.Dot(p1), R3.Dot(p2), R3.Dot(p3)},
+	}
+
+	A := (-d[0][1]*tau3/tau + d[1][1] + d[2][1]*tau1/tau) / d0
+	B := (d[0][1]*(tau3*tau3-tau*tau)*tau3/tau + d[2][1]*(tau*tau-tau1*tau1)*tau1/tau) / (6 * d0)
+	E := R2.Dot(rho2)
+
+	// Distance of the middle position: x^8 + a x^6 + b x^3 + c = 0
+	a := -(A*A + 2*A*E + R2.Dot(R2))
+	b := -2 * mu * B * (A + E)
+	c := -mu * mu * B * B
+	r2, err := distanceRoot(a, b, c)
+	if err != nil {
+		return model.StateVector{}, err
+	}
+	r2cubed := r2 * r2 * r2
+
+	// Slant ranges from the truncated f and g series
+	s1 := ((6*(d[2][0]*tau1/tau3+d[1][0]*tau/tau3)*r2cubed+mu*d[2][0]*(tau*tau-tau1*tau1)*tau1/tau3)/
+		(6*r2cubed+mu*(tau*tau-tau3*tau3)) - d[0][0]) / d0
+	s2 := A + mu*B/r2cubed
+	s3 := ((6*(d[0][2]*tau3/tau1-d[1][2]*tau/tau1)*r2cubed+mu*d[0][2]*(tau*tau-tau3*tau3)*tau3/tau1)/
+		(6*r2cubed+mu*(tau*tau-tau1*tau1)) - d[2][2]) / d0
+
+	f1 := 1 - 0.5*mu*tau1*tau1/r2cubed
+	f3 := 1 - 0.5*mu*tau3*tau3/r2cubed
+	g1 := tau1 - mu*tau1*tau1*tau1/(6*r2cubed)
+	g3 := tau3 - mu*tau3*tau3*tau3/(6*r2cubed)
+
+	var r, v vecmath.Vec3
+	for i := 0; ; i++ {
+		r1 := R1.Add(rho1.Scale(s1))
+		r = R2.Add(rho2.Scale(s2))
+		r3 := R3.Add(rho3.Scale(s3))
+		v = r1.Scale(-f3).Add(r3.Scale(f1)).Scale(1 / (f1*g3 - f3*g1))
+		if i == gaussIterations {
+			break
+		}
+
+		// Exact Lagrange coefficients, averaged with the previous ones for stability
+		f1new, g1new, err := lagrange(r, v, tau1)
+		if err != nil {
+			return model.StateVector{}, err
+		}
+		f3new, g3new, err := lagrange(r, v, tau3)
+		if err != nil {
+			return model.StateVector{}, err
+		}
+		f1, g1 = (f1+f1new)/2, (g1+g1new)/2
+		f3, g3 = (f3+f3new)/2, (g3+g3new)/2
+
+		c1 := g3 / (f1*g3 - f3*g1)
+		c3 := -g1 / (f1*g3 - f3*g1)
+		n1 := (-d[0][0] + d[1][0]/c1 - c3/c1*d[2][0]) / d0
+		n2 := (-c1*d[0][1] + d[1][1] - c3*d[2][1]) / d0
+		n3 := (-c1/c3*d[0][2] + d[1][2]/c3 - d[2][2]) / d0
+
+		converged := math.Abs(n1-s1) < 1e-9*s1 && math.Abs(n2-s2) < 1e-9*s2 && math.Abs(n3-s3) < 1e-9*s3
+		s1, s2, s3 = n1, n2, n3
+		if math.IsNaN(s2) || s2 <= 0 {
+			return model.StateVector{}, errors.New("Gauss iteration diverged")
+		}
+		if converged {
+			break
+		}
+	}
+
+	return newState(o2.Time, r, v), nil
+}
+
+// distanceRoot returns the smallest root above the Earth's surface of
+// x^8 + a x^6 + b x^3 + c = 0, from the eigenvalues of its companion matrix
+func distanceRoot(a, b, c float64) (float64, error) {
+	// Coefficients by power of x, scaled by the Earth radius to keep them comparable
+	s := util.EarthRadius
+	var coefficients [8]float64
+	coefficients[6] = a / (s * s)
+	coefficients[3] = b / math.Pow(s, 5)
+	coefficients[0] = c / math.Pow(s, 8)
+
+	// Companion matrix: ones below the diagonal and the negated coefficients in the last column
+	companion := mat.NewDense(8, 8, nil)
+	for i := 0; i < 8; i++ {
+		if i > 0 {
+			companion.Set(i, i-1, 1)
+		}
+		companion.Set(i, 7, -coefficients[i])
+	}
+
+	var eigen mat.Eigen
+	if !eigen.Factorize(companion, mat.EigenNone) {
+		return 0, errors.New("no solution for the distance of the middle observation")
+	}
+	best := math.Inf(1)
+	for _, root := range eigen.Values(nil) {
+		if math.Abs(imag(root)) > 1e-9*math.Abs(real(root)) || real(root) <= 1 {
+			continue
+		}
+		best = math.Min(best, real(root)*s)
+	}
+	if math.IsInf(best, 1) {
+		return 0, errors.New("no distance above the Earth's surface for the middle observation")
+	}
+	return best, nil
+}
+
+// Gibbs determines the velocity at the middle of three coplanar position vectors with
+// Gibbs's method (Curtis, Algorithm 5.1); the state is at time t2 of r2
+func Gibbs(r1, r2, r3 vecmath.Vec3, t2 time.Time) (model.StateVector, error) {
+	n1, n2, n3 := r1.Norm(), r2.Norm(), r3.Norm()
+	c12, c23, c31 := r1.Cross(r2), r2.Cross(r3), r3.Cross(r1)
+	if math.Abs(r1.Unit().Dot(c23.Unit())) > 0.01 {
+		return model.StateVector{}, errors.New("position vectors are not coplanar")
+	}
+
+	n := c23.Scale(n1).Add(c31.Scale(n2)).Add(c12.Scale(n3))
+	d := c12.Add(c23).Add(c31)
+	s := r1.Scale(n2 - n3).Add(r2.Scale(n3 - n1)).Add(r3.Scale(n1 - n2))
+	if n.Norm() == 0 || d.Norm() == 0 {
+		return model.StateVector{}, errors.New("degenerate position vectors")
+	}
+
+	v := d.Cross(r2).Scale(1 / n2).Add(s).Scale(math.Sqrt(orbital.EarthMu / (n.Norm() * d.Norm())))
+	return newState(t2, r2, v), nil
+}
+
+// HerrickGibbs determines the velocity at the middle of three closely spaced position
+// vectors from a Taylor series (Vallado, Fundamentals of Astrodynamics, Algorithm 55)
+func HerrickGibbs(r1, r2, r3 vecmath.Vec3, t1, t2, t3 time.Time) (model.StateVector, error) {
+	dt21 := t2.Sub(t1).Seconds()
+	dt32 := t3.Sub(t2).Seconds()
+	dt31 := t3.Sub(t1).Seconds()
+	if dt21 <= 0 || dt32 <= 0 {
+		return model.StateVector{}, errors.New("positions must be at distinct, increasing times")
+	}
+
+	mu := orbital.EarthMu
+	n1, n2, n3 := r1.Norm(), r2.Norm(), r3.Norm()
+	k1 := -dt32 * (1/(dt21*dt31) + mu/(12*n1*n1*n1))
+	k2 := (dt32 - dt21) * (1/(dt21*dt32) + mu/(12*n2*n2*n2))
+	k3 := dt21 * (1/(dt32*dt31) + mu/(12*n3*n3*n3))
+
+	v := r1.Scale(k1).Add(r2.Scale(k2)).Add(r3.Scale(k3))
+	return newState(t2, r2, v), nil
+}
+
+// lagrange returns the Lagrange coefficients f and g that propagate the state (r, v)
+// by dt seconds on a Keplerian orbit, from the universal anomaly (Curtis, Algorithm 3.3)
+func lagrange(r, v vecmath.Vec3, dt float64) (float64, float64, error) {
+	mu := orbital.EarthMu
+	sqrtMu := math.Sqrt(mu)
+	r0 := r.Norm()
+	vr0 := r.Dot(v) / r0
+	alpha := 2/r0 - v.Dot(v)/mu
+
+	chi := sqrtMu * math.Abs(alpha) * dt
+	for i := 0; i < 50; i++ {
+		z := alpha * chi * chi
+		c, s := stumpffC(z), stumpffS(z)
+		F := r0*vr0/sqrtMu*chi*chi*c + (1-alpha*r0)*chi*chi*chi*s + r0*chi - sqrtMu*dt
+		dF := r0*vr0/sqrtMu*chi*(1-z*s) + (1-alpha*r0)*chi*chi*c + r0
+		step := F / dF
+		chi -= step
+		if math.Abs(step) < 1e-10 {
+			z = alpha * chi * chi
+			return 1 - chi*chi/r0*stumpffC(z), dt - chi*chi*chi/sqrtMu*stumpffS(z), nil
+		}
+	}
+	return 0, 0, errors.New("universal Kepler equation did not converge")
+}
+
+// stumpffC is the Stumpff function C(z)
+func stumpffC(z float64) float64 {
+	switch {
+	case z > 0:
+		return (1 - math.Cos(math.Sqrt(z))) / z
+	case z < 0:
+		return (math.Cosh(math.Sqrt(-z)) - 1) / -z
+	default:
+		return 0.5
+	}
+}
+
+// stumpffS is the Stumpff function S(z)
+func stumpffS(z float64) float64 {
+	switch {
+	case z > 0:
+		sz := math.Sqrt(z)
+		return (sz - math.Sin(sz)) / (sz * sz * sz)
+	case z < 0:
+		sz := math.Sqrt(-z)
+		return (math.Sinh(sz) - sz) / (sz * sz * sz)
+	default:
+		return 1.0 / 6
+	}
+}
+
+// angleBetween returns the angle between two vectors [degree]
+func angleBetween(a, b vecmath.Vec3) float64 {
+	return util.Rad2Deg(math.Atan2(a.Cross(b).Norm(), a.Dot(b)))
+}
+
+// newState packs an equatorial position and velocity into a state vector
+func newState(t time.Time, position, velocity vecmath.Vec3) model.StateVector {
+	return model.StateVector{
+		Time: t.UTC(),
+		X:    position.X, Y: position.Y, Z: position.Z,
+		VX: velocity.X, VY: velocity.Y, VZ: velocity.Z,
+	}
+}
@@ -0,0 +1,129 @@
+package iod_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"os"
+	"testing"
+	"time"
+
+	"starlink/pkg/iod"
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+	"starlink/pkg/tle"
+	"starlink/pkg/vecmath"
+)
+
+// testSatellite is the tle.txt object the observations are simulated from
+const testSatellite = "STARLINK-1008"
+
+// source returns the propagator of the test satellite, the time of the middle
+// observation and a station under the satellite at that time
+func source(t *testing.T) (*orbital.Propagator, time.Time, *observer.Station) {
+	t.Helper()
+	data, err := os.ReadFile("../../tle.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line1, line2, err := tle.FindSatelliteByName(string(data), testSatellite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := orbital.NewPropagator(tle.ParseTleFromStrings(line1, line2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	middle := p.Epoch().Add(6 * time.Hour)
+	location := p.Location(middle)
+	station := observer.NewStation(model.Observer{Lat: location.Lat + 3, Lng: location.Lng - 2})
+	return p, middle, station
+}
+
+// observe simulates observations spaced by spacing around middle
+func observe(p *orbital.Propagator, middle time.Time, station *observer.Station, spacing time.Duration,
+	count int, ranged bool) []iod.Observation {
+	var times []time.Time
+	for i := range count {
+		times = append(times, middle.Add(time.Duration(i-count/2)*spacing))
+	}
+	return iod.Simulate(testSatellite, p, station, times, ranged)
+}
+
+// checkState compares a determined state with the source propagator's state
+func checkState(t *testing.T, name string, state model.StateVector, p *orbital.Propagator,
+	maxPosition, maxVelocity float64) {
+	t.Helper()
+	want := p.StateECI(state.Time)
+	dr := vecmath.Vec3{X: state.X - want.X, Y: state.Y - want.Y, Z: state.Z - want.Z}.Norm()
+	dv := vecmath.Vec3{X: state.VX - want.VX, Y: state.VY - want.VY, Z: state.VZ - want.VZ}.Norm()
+	if dr > maxPosition || dv > maxVelocity {
+		t.Errorf("%s: state off by %.3f km and %.5f km/s, want within %.3f km and %.5f km/s",
+			name, dr, dv, maxPosition, maxVelocity)
+	}
+}
+
+func TestGaussAnglesOnly(t *testing.T) {
+	p, middle, station := source(t)
+	obs := observe(p, middle, station, time.Minute, 3, false)
+
+	state, err := iod.Gauss(obs[0], obs[1], obs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, "Gauss", state, p, 5, 0.05)
+}
+
+func TestGibbsWideSpacing(t *testing.T) {
+	p, middle, station := source(t)
+	obs := observe(p, middle, station, 4*time.Minute, 3, true)
+
+	state, err := iod.Gibbs(obs[0].Position(), obs[1].Position(), obs[2].Position(), obs[1].Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, "Gibbs", state, p, 1e-3, 2e-3)
+}
+
+func TestHerrickGibbsCloseSpacing(t *testing.T) {
+	p, middle, station := source(t)
+	obs := observe(p, middle, station, 10*time.Second, 3, true)
+
+	state, err := iod.HerrickGibbs(obs[0].Position(), obs[1].Position(), obs[2].Position(),
+		obs[0].Time, obs[1].Time, obs[2].Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, "HerrickGibbs", state, p, 1e-3, 1e-3)
+}
+
+func TestRefineNoisy(t *testing.T) {
+	p, middle, station := source(t)
+	obs := observe(p, middle, station, 20*time.Second, 15, false)
+	iod.AddNoise(obs, iod.DefaultAngleNoise, 0, rand.New(rand.NewPCG(1, 2)))
+
+	initial, _, err := iod.Determine(obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := iod.Refine(obs, initial, p.Elements(), iod.RefineOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !solution.Converged {
+		t.Errorf("refinement did not converge in %d iterations", solution.Iterations)
+	}
+
+	refined, err := orbital.NewPropagator(solution.Elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worst := 0.0
+	for _, o := range obs {
+		worst = math.Max(worst, refined.PositionECI(o.Time).Sub(p.PositionECI(o.Time)).Norm())
+	}
+	if worst > 0.5 {
+		t.Errorf("refined positions off the source by up to %.3f km over the track", worst)
+	}
+}
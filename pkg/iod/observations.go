@@ -0,0 +1,129 @@
+package iod
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+)
+
+// Simulate generates noise-free observations of the satellite that p propagates from
+// the station at each time, with ranges when ranged is set
+func Simulate(name string, p *orbital.Propagator, station *observer.Station, times []time.Time, ranged bool) []Observation {
+	observations := make([]Observation, 0, len(times))
+	for _, t := range times {
+		ra, dec, rng := station.Topocentric(p.PositionECI(t), t)
+		o := Observation{Satellite: name, Time: t.UTC(), Station: station, RightAscension: ra, Declination: dec}
+		if ranged {
+			o.Range = rng
+		}
+		observations = append(observations, o)
+	}
+	return observations
+}
+
+// AddNoise adds Gaussian noise with the given standard deviations to the angles
+// [arcsec] and ranges [km] of the observations
+func AddNoise(observations []Observation, angleNoise, rangeNoise float64, rng *rand.Rand) {
+	for i := range observations {
+		o := &observations[i]
+		o.Declination += rng.NormFloat64() * angleNoise / 3600
+		o.RightAscension += rng.NormFloat64() * angleNoise / 3600 / math.Cos(o.Declination*math.Pi/180)
+		o.RightAscension = math.Mod(o.RightAscension+360, 360)
+		if o.Range > 0 {
+			o.Range += rng.NormFloat64() * rangeNoise
+		}
+	}
+}
+
+// WriteCSV writes observations as CSV rows of satellite, RFC 3339 time, right
+// ascension and declination [degree] and range [km], empty when not measured
+func WriteCSV(w io.Writer, observations []Observation) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("satellite,time,ra_deg,dec_deg,range_km\n"); err != nil {
+		return err
+	}
+	for _, o := range observations {
+		rng := ""
+		if o.Range > 0 {
+			rng = strconv.FormatFloat(o.Range, 'f', 6, 64)
+		}
+		if _, err := fmt.Fprintf(bw, "%s,%s,%.7f,%.7f,%s\n", o.Satellite,
+			o.Time.Format(time.RFC3339Nano), o.RightAscension, o.Declination, rng); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadCSV reads the observations of a station from a CSV file
+func LoadCSV(path string, station *observer.Station) ([]Observation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open observations: %w", err)
+	}
+	defer file.Close()
+
+	return ParseCSV(file, station)
+}
+
+// ParseCSV parses observations of a station in the format written by WriteCSV. The
+// range column may be empty or missing. Blank lines, lines starting with # and the
+// header row are ignored.
+func ParseCSV(r io.Reader, station *observer.Station) ([]Observation, error) {
+	var observations []Observation
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 4 || len(fields) > 5 {
+			return nil, fmt.Errorf("observations line %d: expected satellite,time,ra,dec[,range]", lineNo)
+		}
+		if strings.TrimSpace(fields[1]) == "time" {
+			continue // Header row
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("observations line %d: invalid time: %w", lineNo, err)
+		}
+		o := Observation{Satellite: strings.TrimSpace(fields[0]), Time: t.UTC(), Station: station}
+		o.RightAscension, err = strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err == nil {
+			o.Declination, err = strconv.ParseFloat(strings.TrimSpace(fields[3]), 64)
+		}
+		if err == nil && len(fields) == 5 && strings.TrimSpace(fields[4]) != "" {
+			o.Range, err = strconv.ParseFloat(strings.TrimSpace(fields[4]), 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("observations line %d: invalid number in %q", lineNo, line)
+		}
+		if o.Declination < -90 || o.Declination > 90 || o.Range < 0 {
+			return nil, fmt.Errorf("observations line %d: value out of range", lineNo)
+		}
+		observations = append(observations, o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(observations) == 0 {
+		return nil, fmt.Errorf("no observations")
+	}
+
+	return observations, nil
+}
@@ -0,0 +1,117 @@
+package iod
+
+import (
+	"errors"
+	"math"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/tlefit"
+	"starlink/pkg/util"
+)
+
+// Default measurement noise used to weight the residuals
+const (
+	DefaultAngleNoise = 2.0  // Angle noise [arcsec]
+	DefaultRangeNoise = 0.01 // Range noise [km]
+)
+
+// RefineOptions configure the least-squares refinement. The zero value uses the
+// default noise and an epoch at the time of the initial state.
+type RefineOptions struct {
+	AngleNoise float64        // Angle measurement noise [arcsec], DefaultAngleNoise when zero
+	RangeNoise float64        // Range measurement noise [km], DefaultRangeNoise when zero
+	Fit        tlefit.Options // Epoch, drag and iteration options of the differential correction
+}
+
+// Residuals summarize the observed minus computed measurements of an element set
+type Residuals struct {
+	Observations   int     // Number of observations
+	Ranged         int     // Number of observations with a range
+	RMSAscension   float64 // RMS of the right ascension residual times cos(declination) [arcsec]
+	RMSDeclination float64 // RMS of the declination residual [arcsec]
+	RMSRange       float64 // RMS of the range residual [km], zero without ranges
+}
+
+// Solution is an element set determined from observations
+type Solution struct {
+	Elements   *model.TleOrbitalElement // Refined mean elements
+	Iterations int                      // Differential correction iterations
+	Converged  bool                     // Whether the correction converged
+	Residuals  Residuals                // Measurement residuals of the refined elements
+}
+
+// Refine improves an initial state to the mean element set that best fits all
+// observations in a weighted least-squares sense, by differential correction through
+// the analytic propagator. Right ascension and declination residuals are weighted by
+// the angle noise and ranges by the range noise. Light time is neglected. The
+// identification of the element set is copied from template, which may be nil, and so
+// are the drag terms unless Fit.FitDrag fits them as tlefit.Fit does.
+func Refine(observations []Observation, initial model.StateVector, template *model.TleOrbitalElement,
+	opts RefineOptions) (Solution, error) {
+	if len(observations) < 3 {
+		return Solution{}, errors.New("at least three observations are needed for a refinement")
+	}
+	if opts.AngleNoise <= 0 {
+		opts.AngleNoise = DefaultAngleNoise
+	}
+	if opts.RangeNoise <= 0 {
+		opts.RangeNoise = DefaultRangeNoise
+	}
+
+	angleWeight := 1 / util.Deg2Rad(opts.AngleNoise/3600)
+	residualsOf := func(p *orbital.Propagator) []float64 {
+		residuals := make([]float64, 0, 3*len(observations))
+		for _, o := range observations {
+			dra, ddec, dr := residual(p, o)
+			residuals = append(residuals, angleWeight*util.Deg2Rad(dra), angleWeight*util.Deg2Rad(ddec))
+			if o.Range > 0 {
+				residuals = append(residuals, dr/opts.RangeNoise)
+			}
+		}
+		return residuals
+	}
+
+	result, err := tlefit.Correct(initial, template, residualsOf, opts.Fit)
+	if err != nil {
+		return Solution{}, err
+	}
+	solution := Solution{Elements: result.Elements, Iterations: result.Iterations, Converged: result.Converged}
+	if p, err := orbital.NewPropagator(result.Elements); err == nil {
+		solution.Residuals = Evaluate(p, observations)
+	}
+	return solution, nil
+}
+
+// Evaluate computes the measurement residuals of the element set that p propagates
+func Evaluate(p *orbital.Propagator, observations []Observation) Residuals {
+	r := Residuals{Observations: len(observations)}
+	var ra, dec, rng float64
+	for _, o := range observations {
+		dra, ddec, dr := residual(p, o)
+		ra += dra * dra
+		dec += ddec * ddec
+		if o.Range > 0 {
+			r.Ranged++
+			rng += dr * dr
+		}
+	}
+
+	if r.Observations > 0 {
+		n := float64(r.Observations)
+		r.RMSAscension = math.Sqrt(ra/n) * 3600
+		r.RMSDeclination = math.Sqrt(dec/n) * 3600
+	}
+	if r.Ranged > 0 {
+		r.RMSRange = math.Sqrt(rng / float64(r.Ranged))
+	}
+	return r
+}
+
+// residual returns the observed minus computed right ascension times cos(declination)
+// [degree], declination [degree] and range [km] of an observation
+func residual(p *orbital.Propagator, o Observation) (float64, float64, float64) {
+	ra, dec, rng := o.Station.Topocentric(p.PositionECI(o.Time), o.Time)
+	dra := math.Remainder(o.RightAscension-ra, 360) * math.Cos(util.Deg2Rad(dec))
+	return dra, o.Declination - dec, o.Range - rng
+}
@@ -32,4 +32,14 @@ func NewtonRaphson(e, before, a float64) (float64, float64) {
 	}
 	
 	return after, err
-}
\ No newline at end of file
+}
+
+// SolveKepler solves the Kepler equation E - e*sin(E) = M for the eccentric anomaly
+// with the same fixed number of Newton-Raphson iterations as NewtonRaphson
+func SolveKepler(e, M float64) float64 {
+	E := M
+	for i := 0; i < 10; i++ {
+		E = E - (E-e*math.Sin(E)-M)/(1-e*math.Cos(E))
+	}
+	return E
+}
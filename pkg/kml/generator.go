@@ -2,13 +2,23 @@ package kml
 
 import (
 	"fmt"
+	"html"
+	"strings"
 	"time"
 
+	"starlink/pkg/footprint"
 	"starlink/pkg/model"
 )
 
 // GenerateKML creates a KML document for satellite location visualization
 func GenerateKML(satellites []string, locations map[string]*model.SatLocation, timestamp time.Time) string {
+	return GenerateKMLWithFootprints(satellites, locations, nil, timestamp)
+}
+
+// GenerateKMLWithFootprints creates a KML document for satellite location visualization
+// with the coverage footprint of each satellite that has one drawn as a polygon
+func GenerateKMLWithFootprints(satellites []string, locations map[string]*model.SatLocation,
+	footprints map[string]footprint.Footprint, timestamp time.Time) string {
 	// KML header
 	kml := `<?xml version="1.0" encoding="UTF-8"?>
 <kml xmlns="http://www.opengis.net/kml/2.2">
@@ -26,6 +36,15 @@ func GenerateKML(satellites []string, locations map[string]*model.SatLocation, t
 			<scale>0.8</scale>
 		</LabelStyle>
 	</Style>
+	<Style id="footprint">
+		<LineStyle>
+			<color>ff00aaff</color>
+			<width>1</width>
+		</LineStyle>
+		<PolyStyle>
+			<color>3300aaff</color>
+		</PolyStyle>
+	</Style>
 `
 	// Format the timestamp for description
 	kml = fmt.Sprintf(kml, timestamp.Format(time.RFC3339))
@@ -43,6 +62,7 @@ func GenerateKML(satellites []string, locations map[string]*model.SatLocation, t
 		<description>
 			Altitude: %.3f km
 			Velocity: %.3f km/s
+			In shadow: %t%s
 		</description>
 		<styleUrl>#satellite</styleUrl>
 		<Point>
@@ -50,16 +70,28 @@ func GenerateKML(satellites []string, locations map[string]*model.SatLocation, t
 		</Point>
 	</Placemark>
 `
+		// Element set health warnings, one per line
+		warnings := ""
+		for _, warning := range location.Warnings {
+			warnings += "\n\t\t\tWarning: " + html.EscapeString(warning)
+		}
+
 		// Append formatted placemark to KML
 		placemark = fmt.Sprintf(placemark, 
 			satName, 
 			location.Alt, 
 			location.Velocity, 
+			location.InShadow,
+			warnings,
 			location.Lng,  // Longitude goes first in KML
 			location.Lat,  // Then latitude
 			location.Alt*1000) // Altitude in meters for KML
 		
 		kml += placemark
+
+		if fp, ok := footprints[satName]; ok {
+			kml += footprintPlacemark(satName, fp)
+		}
 	}
 
 	// Add footer
@@ -67,4 +99,27 @@ func GenerateKML(satellites []string, locations map[string]*model.SatLocation, t
 </kml>`
 
 	return kml
-}
\ No newline at end of file
+}
+
+// footprintPlacemark draws a footprint as a multi-polygon clamped to the ground
+func footprintPlacemark(satName string, fp footprint.Footprint) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `	<Placemark>
+		<name>%s footprint</name>
+		<description>Minimum elevation %.1f°, radius %.0f km</description>
+		<styleUrl>#footprint</styleUrl>
+		<MultiGeometry>
+`, html.EscapeString(satName), fp.MinElevation, fp.Radius)
+
+	for _, ring := range fp.Polygons() {
+		b.WriteString("\t\t\t<Polygon>\n\t\t\t\t<outerBoundaryIs>\n\t\t\t\t\t<LinearRing>\n\t\t\t\t\t\t<coordinates>\n")
+		for _, point := range ring {
+			fmt.Fprintf(&b, "\t\t\t\t\t\t\t%.6f,%.6f,0\n", point.Lng, point.Lat)
+		}
+		b.WriteString("\t\t\t\t\t\t</coordinates>\n\t\t\t\t\t</LinearRing>\n\t\t\t\t</outerBoundaryIs>\n\t\t\t</Polygon>\n")
+	}
+
+	b.WriteString("\t\t</MultiGeometry>\n\t</Placemark>\n")
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package kml
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"starlink/pkg/groundtrack"
+)
+
+// GenerateGroundTrackKML creates a KML document with one folder per satellite holding
+// its ground track segments as line strings and its equator crossings as points
+func GenerateGroundTrackKML(tracks []groundtrack.Track, timestamp time.Time) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+	<name>Starlink Ground Tracks</name>
+	<description>Ground tracks from %s</description>
+	<Style id="track">
+		<LineStyle>
+			<color>ff00ffff</color>
+			<width>2</width>
+		</LineStyle>
+	</Style>
+	<Style id="node">
+		<IconStyle>
+			<Icon>
+				<href>http://maps.google.com/mapfiles/kml/shapes/placemark_circle.png</href>
+			</Icon>
+			<scale>0.6</scale>
+		</IconStyle>
+		<LabelStyle>
+			<scale>0.6</scale>
+		</LabelStyle>
+	</Style>
+`, timestamp.Format(time.RFC3339))
+
+	for _, track := range tracks {
+		fmt.Fprintf(&b, "\t<Folder>\n\t\t<name>%s</name>\n", html.EscapeString(track.Satellite))
+
+		for _, segment := range track.Segments {
+			if len(segment) < 2 {
+				continue
+			}
+			b.WriteString("\t\t<Placemark>\n\t\t\t<styleUrl>#track</styleUrl>\n\t\t\t<LineString>\n\t\t\t\t<tessellate>1</tessellate>\n\t\t\t\t<coordinates>\n")
+			for _, point := range segment {
+				// Drawn on the ground; longitude goes first in KML
+				fmt.Fprintf(&b, "\t\t\t\t\t%.6f,%.6f,0\n", point.Lng, point.Lat)
+			}
+			b.WriteString("\t\t\t\t</coordinates>\n\t\t\t</LineString>\n\t\t</Placemark>\n")
+		}
+
+		for _, node := range track.Nodes {
+			label := "DN"
+			if node.Ascending {
+				label = "AN"
+			}
+			fmt.Fprintf(&b, `		<Placemark>
+			<name>%s</name>
+			<description>%s</description>
+			<styleUrl>#node</styleUrl>
+			<Point>
+				<coordinates>%.6f,%.6f,0</coordinates>
+			</Point>
+		</Placemark>
+`, label, node.Time.Format(time.RFC3339), node.Lng, node.Lat)
+		}
+
+		b.WriteString("\t</Folder>\n")
+	}
+
+	b.WriteString("</Document>\n</kml>")
+	return b.String()
+}
@@ -0,0 +1,305 @@
+package maneuver
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+)
+
+// DefaultSigma is the number of robust standard deviations of the element set
+// residuals beyond which a change is attributed to a maneuver
+const DefaultSigma = 5.0
+
+// Minimum detectable changes, which keep a very regular history from flagging noise
+const (
+	DefaultSemiMajorAxisFloor = 0.2   // Semi-major axis change [km]
+	DefaultInclinationFloor   = 0.005 // Inclination change [degree]
+	DefaultRaanFloor          = 0.02  // RAAN change [degree]
+)
+
+// maxSearchSamples is the number of samples of the maneuver epoch search
+const maxSearchSamples = 2000
+
+// Options configure maneuver detection. Zero values select the defaults.
+type Options struct {
+	Sigma              float64 // Detection threshold in robust standard deviations, DefaultSigma when zero
+	SemiMajorAxisFloor float64 // Minimum semi-major axis change [km], DefaultSemiMajorAxisFloor when zero
+	InclinationFloor   float64 // Minimum inclination change [degree], DefaultInclinationFloor when zero
+	RaanFloor          float64 // Minimum RAAN change [degree], DefaultRaanFloor when zero
+}
+
+// Kind is the dominant effect of a maneuver
+type Kind int
+
+const (
+	// KindRaise is an in-plane maneuver that raises the orbit
+	KindRaise Kind = iota
+	// KindLower is an in-plane maneuver that lowers the orbit, as for a deorbit
+	KindLower
+	// KindPlaneChange is an out-of-plane maneuver changing inclination or RAAN
+	KindPlaneChange
+)
+
+// String returns the name of the kind of maneuver
+func (k Kind) String() string {
+	switch k {
+	case KindRaise:
+		return "raise"
+	case KindLower:
+		return "lower"
+	case KindPlaneChange:
+		return "plane change"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// History is the time-ordered element sets of one object
+type History struct {
+	CatalogNumber string                     // NORAD catalog number
+	Name          string                     // Object name, from the first named element set
+	Elements      []*model.TleOrbitalElement // Element sets in epoch order
+}
+
+// Maneuver is a change of orbit between two consecutive element sets that the
+// propagator's drag and J2 model does not explain
+type Maneuver struct {
+	Epoch       time.Time // Estimated maneuver time
+	WindowStart time.Time // Epoch of the last element set before the maneuver
+	WindowEnd   time.Time // Epoch of the first element set after the maneuver
+	Kind        Kind      // Dominant effect
+
+	DeltaSemiMajorAxis float64 // Unexplained semi-major axis change [km]
+	DeltaInclination   float64 // Unexplained inclination change [degree]
+	DeltaRaan          float64 // Unexplained RAAN change [degree]
+
+	DeltaVInTrack    float64 // In-track delta-V, negative when braking [m/s]
+	DeltaVCrossTrack float64 // Cross-track (plane change) delta-V magnitude [m/s]
+	DeltaV           float64 // Total delta-V [m/s]
+}
+
+// Thresholds are the changes beyond which an element set pair indicates a maneuver
+type Thresholds struct {
+	SemiMajorAxis float64 // Semi-major axis [km]
+	Inclination   float64 // Inclination [degree]
+	Raan          float64 // RAAN [degree]
+}
+
+// Log is the maneuver history of one object
+type Log struct {
+	CatalogNumber string     // NORAD catalog number
+	Name          string     // Object name
+	ElementSets   int        // Number of element sets analyzed
+	First         time.Time  // Epoch of the first element set
+	Last          time.Time  // Epoch of the last element set
+	Thresholds    Thresholds // Detection thresholds derived from the history
+	Maneuvers     []Maneuver // Detected maneuvers in time order
+	TotalDeltaV   float64    // Sum of the maneuvers' delta-V [m/s]
+}
+
+// residuals are the observed minus predicted changes between consecutive element sets
+type residuals struct {
+	prev, next         *orbital.Propagator
+	semiMajorAxis      float64 // [km]
+	inclination, raan  float64 // [degree]
+	predictedSemiMajor float64 // Predicted semi-major axis at the later epoch [km]
+}
+
+// Histories groups element sets by catalog number, in the order the objects first
+// appear, and sorts each history by epoch. Of several element sets with the same
+// epoch only the last is kept.
+func Histories(sets []model.Satellite) []History {
+	var histories []History
+	index := make(map[string]int)
+	for _, set := range sets {
+		number := set.Elements.CatalogNumber
+		i, ok := index[number]
+		if !ok {
+			i = len(histories)
+			index[number] = i
+			histories = append(histories, History{CatalogNumber: number})
+		}
+		if histories[i].Name == "" && set.Name != number {
+			histories[i].Name = set.Name
+		}
+		histories[i].Elements = append(histories[i].Elements, set.Elements)
+	}
+
+	for i := range histories {
+		h := &histories[i]
+		if h.Name == "" {
+			h.Name = h.CatalogNumber
+		}
+		sort.SliceStable(h.Elements, func(a, b int) bool {
+			return orbital.EpochTime(h.Elements[a]).Before(orbital.EpochTime(h.Elements[b]))
+		})
+		unique := h.Elements[:0]
+		for _, el := range h.Elements {
+			if n := len(unique); n > 0 && orbital.EpochTime(unique[n-1]).Equal(orbital.EpochTime(el)) {
+				unique[n-1] = el
+				continue
+			}
+			unique = append(unique, el)
+		}
+		h.Elements = unique
+	}
+	return histories
+}
+
+// Detect finds the maneuvers in the history of an object. Each element set is
+// propagated to the epoch of the next with the mean motion derivative (drag, at the
+// rate of orbital.MeanMotionRate) and the J2 secular rates, and changes of semi-major
+// axis, inclination and RAAN beyond the thresholds are reported as maneuvers. The
+// thresholds are the larger of the floors and Sigma times the robust standard deviation
+// (scaled median absolute deviation) of the history's residuals, so they adapt to the
+// element set noise. The delta-V follows from the Gauss variational equations for a
+// near-circular orbit, and the maneuver epoch is where the trajectories of the two
+// element sets come closest.
+func Detect(h History, opts Options) Log {
+	if opts.Sigma <= 0 {
+		opts.Sigma = DefaultSigma
+	}
+	if opts.SemiMajorAxisFloor <= 0 {
+		opts.SemiMajorAxisFloor = DefaultSemiMajorAxisFloor
+	}
+	if opts.InclinationFloor <= 0 {
+		opts.InclinationFloor = DefaultInclinationFloor
+	}
+	if opts.RaanFloor <= 0 {
+		opts.RaanFloor = DefaultRaanFloor
+	}
+
+	log := Log{CatalogNumber: h.CatalogNumber, Name: h.Name, ElementSets: len(h.Elements)}
+	if len(h.Elements) > 0 {
+		log.First = orbital.EpochTime(h.Elements[0])
+		log.Last = orbital.EpochTime(h.Elements[len(h.Elements)-1])
+	}
+
+	// Residuals of each pair of consecutive element sets; invalid sets are skipped
+	var pairs []residuals
+	var prev *orbital.Propagator
+	for _, el := range h.Elements {
+		next, err := orbital.NewPropagator(el)
+		if err != nil {
+			continue
+		}
+		if prev != nil {
+			pairs = append(pairs, pairResiduals(prev, next))
+		}
+		prev = next
+	}
+	if len(pairs) == 0 {
+		return log
+	}
+
+	semiMajor := make([]float64, len(pairs))
+	inclination := make([]float64, len(pairs))
+	raan := make([]float64, len(pairs))
+	for i, r := range pairs {
+		semiMajor[i], inclination[i], raan[i] = r.semiMajorAxis, r.inclination, r.raan
+	}
+	log.Thresholds = Thresholds{
+		SemiMajorAxis: math.Max(opts.SemiMajorAxisFloor, opts.Sigma*robustSigma(semiMajor)),
+		Inclination:   math.Max(opts.InclinationFloor, opts.Sigma*robustSigma(inclination)),
+		Raan:          math.Max(opts.RaanFloor, opts.Sigma*robustSigma(raan)),
+	}
+
+	for _, r := range pairs {
+		inPlane := math.Abs(r.semiMajorAxis) > log.Thresholds.SemiMajorAxis
+		outOfPlane := math.Abs(r.inclination) > log.Thresholds.Inclination ||
+			math.Abs(r.raan) > log.Thresholds.Raan
+		if !inPlane && !outOfPlane {
+			continue
+		}
+		m := estimate(r, inPlane, outOfPlane)
+		log.Maneuvers = append(log.Maneuvers, m)
+		log.TotalDeltaV += m.DeltaV
+	}
+	return log
+}
+
+// pairResiduals compares an element set with the prediction of the previous one at its epoch
+func pairResiduals(prev, next *orbital.Propagator) residuals {
+	predicted := prev.MeanElementsAt(next.Epoch())
+	observed := next.Elements()
+	predictedSemiMajor := orbital.SemiMajorAxis(predicted)
+	return residuals{
+		prev:               prev,
+		next:               next,
+		semiMajorAxis:      next.SemiMajorAxis() - predictedSemiMajor,
+		inclination:        observed.OrbitalInclination - predicted.OrbitalInclination,
+		raan:               math.Remainder(observed.Raan-predicted.Raan, 360),
+		predictedSemiMajor: predictedSemiMajor,
+	}
+}
+
+// estimate computes the delta-V and epoch of a maneuver from the residuals of the
+// element set pair that brackets it. Only the components that exceed their thresholds
+// contribute to the delta-V.
+func estimate(r residuals, inPlane, outOfPlane bool) Maneuver {
+	m := Maneuver{
+		WindowStart:        r.prev.Epoch(),
+		WindowEnd:          r.next.Epoch(),
+		DeltaSemiMajorAxis: r.semiMajorAxis,
+		DeltaInclination:   r.inclination,
+		DeltaRaan:          r.raan,
+	}
+
+	// Circular speed at the mean semi-major axis [km/s]
+	a := (r.predictedSemiMajor + r.next.SemiMajorAxis()) / 2
+	speed := math.Sqrt(orbital.EarthMu / a)
+	if inPlane {
+		// da = 2 a dv / v for a tangential impulse on a circular orbit
+		m.DeltaVInTrack = speed * r.semiMajorAxis / (2 * a) * 1000
+	}
+	if outOfPlane {
+		// Angle between the orbit normals
+		i := util.Deg2Rad(r.next.Elements().OrbitalInclination)
+		angle := math.Hypot(util.Deg2Rad(r.inclination), math.Sin(i)*util.Deg2Rad(r.raan))
+		m.DeltaVCrossTrack = 2 * speed * math.Sin(angle/2) * 1000
+	}
+	m.DeltaV = math.Hypot(m.DeltaVInTrack, m.DeltaVCrossTrack)
+
+	switch {
+	case m.DeltaVCrossTrack > math.Abs(m.DeltaVInTrack):
+		m.Kind = KindPlaneChange
+	case m.DeltaVInTrack < 0:
+		m.Kind = KindLower
+	default:
+		m.Kind = KindRaise
+	}
+	m.Epoch = closestApproach(r.prev, r.next, m.WindowStart, m.WindowEnd)
+	return m
+}
+
+// closestApproach returns the time in [start, stop] at which the positions of the two
+// element sets are closest. Before an impulse the later element set, propagated
+// backwards, drifts away from the earlier one and after it the earlier one drifts away
+// from the actual orbit, so the trajectories cross at the maneuver.
+func closestApproach(prev, next *orbital.Propagator, start, stop time.Time) time.Time {
+	step := max(stop.Sub(start)/maxSearchSamples, time.Minute)
+	best, bestDistance := start, math.Inf(1)
+	for t := start; !t.After(stop); t = t.Add(step) {
+		distance := prev.PositionECI(t).Sub(next.PositionECI(t)).Norm()
+		if distance < bestDistance {
+			best, bestDistance = t, distance
+		}
+	}
+	return best
+}
+
+// robustSigma estimates the standard deviation of values as 1.4826 times their
+// median absolute deviation, which maneuvers (outliers) do not inflate
+func robustSigma(values []float64) float64 {
+	center := util.Median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - center)
+	}
+	return 1.4826 * util.Median(deviations)
+}
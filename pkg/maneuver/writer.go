@@ -0,0 +1,30 @@
+package maneuver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteCSV writes the maneuvers of the logs as CSV rows, one per maneuver
+func WriteCSV(w io.Writer, logs []Log) error {
+	bw := bufio.NewWriter(w)
+	header := "catalog_number,name,epoch,window_start,window_end,kind,delta_a_km,delta_i_deg,delta_raan_deg,dv_in_track_m_s,dv_cross_track_m_s,dv_m_s\n"
+	if _, err := bw.WriteString(header); err != nil {
+		return err
+	}
+
+	for _, log := range logs {
+		for _, m := range log.Maneuvers {
+			if _, err := fmt.Fprintf(bw, "%s,%s,%s,%s,%s,%s,%.3f,%.4f,%.4f,%.3f,%.3f,%.3f\n",
+				log.CatalogNumber, log.Name, m.Epoch.Format(time.RFC3339),
+				m.WindowStart.Format(time.RFC3339), m.WindowEnd.Format(time.RFC3339), m.Kind,
+				m.DeltaSemiMajorAxis, m.DeltaInclination, m.DeltaRaan,
+				m.DeltaVInTrack, m.DeltaVCrossTrack, m.DeltaV); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -1,25 +1,80 @@
 package model
 
+import (
+	"time"
+)
+
 // SatLocation represents satellite location and position in space
 type SatLocation struct {
-	X         float64 // X coordinate in Earth-fixed frame [km]
-	Y         float64 // Y coordinate in Earth-fixed frame [km]
-	Z         float64 // Z coordinate in Earth-fixed frame [km]
-	Lat       float64 // Latitude [degree]
-	Lng       float64 // Longitude [degree]
-	Alt       float64 // Altitude from Earth surface [km]
-	Velocity  float64 // Velocity [km/s], optional
+	X        float64  // X coordinate in Earth-fixed frame [km]
+	Y        float64  // Y coordinate in Earth-fixed frame [km]
+	Z        float64  // Z coordinate in Earth-fixed frame [km]
+	Lat      float64  // Latitude [degree]
+	Lng      float64  // Longitude [degree]
+	Alt      float64  // Altitude from Earth surface [km]
+	Velocity float64  // Velocity [km/s], optional
+	Warnings []string // Element set health warnings, optional
+	InShadow bool     // In Earth's shadow (umbra or penumbra), optional
 }
 
 // TleOrbitalElement contains the orbital elements parsed from a TLE
 type TleOrbitalElement struct {
 	MeanAnomaly        float64 // M0 平均近点角 [Degree]
 	MeanMotion         float64 // M1 平均運動: [Rev/Day]
-	MeanMotionDot      float64 // M2 平均運動変化係数: [Rev/Day2]
+	MeanMotionDot      float64 // M2 平均運動変化係数: [Rev/Day2], read through orbital.MeanMotionRate
 	Eccentricity       float64 // 離心率 [-]
 	EtYear             int     // 元期 Epoctime [Year]
 	EtDay              float64 // 元期 EpocTime [Day]
 	OrbitalInclination float64 // 軌道傾斜角 [Degree]
 	Raan               float64 // 昇交点赤経: RAAN [Degree]
 	ArgumentOfPerigee  float64 // 近地点引数 [Degree]
-}
\ No newline at end of file
+	BStar              float64 // B*抗力項: B* drag term [1/EarthRadii]
+
+	CatalogNumber           string // 衛星番号: NORAD catalog number, e.g. "44714"
+	InternationalDesignator string // 国際標識: launch year, number and piece, e.g. "19074B"
+}
+
+// Satellite associates a catalog name with its parsed orbital elements
+type Satellite struct {
+	Name     string             // Satellite name as it appears in the TLE data
+	Elements *TleOrbitalElement // Parsed orbital elements
+}
+
+// StateVector represents satellite position and velocity at an instant
+type StateVector struct {
+	Time time.Time // Time of the state (UTC)
+	X    float64   // Position X [km]
+	Y    float64   // Position Y [km]
+	Z    float64   // Position Z [km]
+	VX   float64   // Velocity X [km/s]
+	VY   float64   // Velocity Y [km/s]
+	VZ   float64   // Velocity Z [km/s]
+}
+
+// Observer is a ground observer on the WGS-84 ellipsoid
+type Observer struct {
+	Name string  // Site name, optional
+	Lat  float64 // Geodetic latitude [degree]
+	Lng  float64 // Longitude [degree]
+	Alt  float64 // Height above the ellipsoid [km]
+
+	Mask *HorizonMask // Azimuth-dependent horizon, optional
+}
+
+// HorizonMask is an azimuth-dependent minimum elevation, such as the skyline of
+// buildings and hills around a site. Points are sorted by azimuth;
+// observer.MaskElevationAt interpolates between them.
+type HorizonMask struct {
+	Azimuths   []float64 // Azimuths of the mask points [degree], ascending in [0, 360)
+	Elevations []float64 // Minimum elevation at each azimuth [degree]
+}
+
+// LookAngle describes where a satellite appears from an observer
+type LookAngle struct {
+	Time      time.Time // Time of the observation (UTC)
+	Azimuth   float64   // Azimuth measured clockwise from north [degree]
+	Elevation float64   // Elevation above the local horizontal [degree]
+	Range     float64   // Slant range [km]
+	RangeRate float64   // Slant range rate, positive when receding [km/s]
+	Visible   bool      // Above the observer's horizon mask (or the horizon without one)
+}
@@ -0,0 +1,223 @@
+package numerical
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// Atmosphere is a model of the atmospheric density
+type Atmosphere interface {
+	// Density returns the density [kg/m3] at time t and a position in the equatorial frame [km]
+	Density(t time.Time, position vecmath.Vec3) float64
+}
+
+// ParseAtmosphere parses an atmosphere model name (exponential, harris-priester or
+// solar-flux, the latter with the default solar activity)
+func ParseAtmosphere(name string) (Atmosphere, error) {
+	switch strings.ToLower(name) {
+	case "exponential":
+		return Exponential{}, nil
+	case "harris-priester", "hp":
+		return HarrisPriester{}, nil
+	case "solar-flux", "flux":
+		return SolarFlux{}, nil
+	default:
+		return nil, fmt.Errorf("unknown atmosphere %q (expected exponential, harris-priester or solar-flux)", name)
+	}
+}
+
+// height returns the altitude above the WGS-84 ellipsoid of a geocentric position [km],
+// approximating the ellipsoid radius at the geocentric latitude
+func height(position vecmath.Vec3) float64 {
+	r := position.Norm()
+	sinLat := position.Z / r
+	return r - util.WGS84EquatorialRadius*(1-util.WGS84Flattening*sinLat*sinLat)
+}
+
+// exponentialTable holds the base altitude [km], density [kg/m3] and scale height [km]
+// of each band of the exponential model (Vallado, Fundamentals of Astrodynamics, 8-4)
+var exponentialTable = [...][3]float64{
+	{0, 1.225, 7.249},
+	{25, 3.899e-2, 6.349},
+	{30, 1.774e-2, 6.682},
+	{40, 3.972e-3, 7.554},
+	{50, 1.057e-3, 8.382},
+	{60, 3.206e-4, 7.714},
+	{70, 8.770e-5, 6.549},
+	{80, 1.905e-5, 5.799},
+	{90, 3.396e-6, 5.382},
+	{100, 5.297e-7, 5.877},
+	{110, 9.661e-8, 7.263},
+	{120, 2.438e-8, 9.473},
+	{130, 8.484e-9, 12.636},
+	{140, 3.845e-9, 16.149},
+	{150, 2.070e-9, 22.523},
+	{180, 5.464e-10, 29.740},
+	{200, 2.789e-10, 37.105},
+	{250, 7.248e-11, 45.546},
+	{300, 2.418e-11, 53.628},
+	{350, 9.518e-12, 53.298},
+	{400, 3.725e-12, 58.515},
+	{450, 1.585e-12, 60.828},
+	{500, 6.967e-13, 63.822},
+	{600, 1.454e-13, 71.835},
+	{700, 3.614e-14, 88.667},
+	{800, 1.170e-14, 124.64},
+	{900, 5.245e-15, 181.05},
+	{1000, 3.019e-15, 268.00},
+}
+
+// Exponential is the static exponential atmosphere, with a scale height for each
+// altitude band
+type Exponential struct{}
+
+// Density implements Atmosphere
+func (Exponential) Density(_ time.Time, position vecmath.Vec3) float64 {
+	h := height(position)
+	if h < 0 {
+		h = 0
+	}
+	i := sort.Search(len(exponentialTable), func(i int) bool { return exponentialTable[i][0] > h }) - 1
+	band := exponentialTable[i]
+	return band[1] * math.Exp(-(h-band[0])/band[2])
+}
+
+// harrisPriesterTable holds the altitude [km] and the minimum and maximum density
+// [g/km3] of the Harris-Priester model for mean solar activity (Montenbruck & Gill,
+// Satellite Orbits, 3.5.2)
+var harrisPriesterTable = [...][3]float64{
+	{100, 4.974e+05, 4.974e+05},
+	{120, 2.490e+04, 2.490e+04},
+	{130, 8.377e+03, 8.710e+03},
+	{140, 3.899e+03, 4.059e+03},
+	{150, 2.122e+03, 2.215e+03},
+	{160, 1.263e+03, 1.344e+03},
+	{170, 8.008e+02, 8.758e+02},
+	{180, 5.283e+02, 6.010e+02},
+	{190, 3.617e+02, 4.297e+02},
+	{200, 2.557e+02, 3.162e+02},
+	{210, 1.839e+02, 2.396e+02},
+	{220, 1.341e+02, 1.853e+02},
+	{230, 9.949e+01, 1.455e+02},
+	{240, 7.488e+01, 1.157e+02},
+	{250, 5.709e+01, 9.308e+01},
+	{260, 4.403e+01, 7.555e+01},
+	{270, 3.430e+01, 6.182e+01},
+	{280, 2.697e+01, 5.095e+01},
+	{290, 2.139e+01, 4.226e+01},
+	{300, 1.708e+01, 3.526e+01},
+	{320, 1.099e+01, 2.511e+01},
+	{340, 7.214e+00, 1.819e+01},
+	{360, 4.824e+00, 1.337e+01},
+	{380, 3.274e+00, 9.955e+00},
+	{400, 2.249e+00, 7.492e+00},
+	{420, 1.558e+00, 5.684e+00},
+	{440, 1.091e+00, 4.355e+00},
+	{460, 7.701e-01, 3.362e+00},
+	{480, 5.474e-01, 2.612e+00},
+	{500, 3.916e-01, 2.042e+00},
+	{520, 2.819e-01, 1.605e+00},
+	{540, 2.042e-01, 1.267e+00},
+	{560, 1.488e-01, 1.005e+00},
+	{580, 1.092e-01, 7.997e-01},
+	{600, 8.070e-02, 6.390e-01},
+	{640, 4.510e-02, 4.123e-01},
+	{680, 2.573e-02, 2.698e-01},
+	{720, 1.511e-02, 1.794e-01},
+	{760, 9.149e-03, 1.224e-01},
+	{800, 5.744e-03, 8.565e-02},
+	{840, 3.751e-03, 6.133e-02},
+	{880, 2.552e-03, 4.487e-02},
+	{920, 1.809e-03, 3.341e-02},
+	{960, 1.337e-03, 2.529e-02},
+	{1000, 1.024e-03, 1.943e-02},
+}
+
+// harrisPriesterLag is the lag of the diurnal bulge behind the subsolar point [Rad]
+const harrisPriesterLag = 30 * math.Pi / 180
+
+// HarrisPriester is the Harris-Priester atmosphere, whose density varies between a
+// night-time minimum and the maximum of the diurnal bulge that trails the Sun by 30°.
+// The density is zero outside 100-1000 km.
+type HarrisPriester struct {
+	// Exponent of the bulge's cosine law: 2 for low-inclination orbits up to 6 for polar
+	// orbits, 4 when zero
+	Exponent float64
+}
+
+// Density implements Atmosphere
+func (m HarrisPriester) Density(t time.Time, position vecmath.Vec3) float64 {
+	h := height(position)
+	first, last := harrisPriesterTable[0], harrisPriesterTable[len(harrisPriesterTable)-1]
+	if h < first[0] || h >= last[0] {
+		return 0
+	}
+	exponent := m.Exponent
+	if exponent <= 0 {
+		exponent = 4
+	}
+
+	// Apex of the diurnal bulge
+	ra, dec, _ := orbital.RightAscensionDeclination(orbital.SunPosition(t))
+	sinDec, cosDec := math.Sincos(util.Deg2Rad(dec))
+	sinRa, cosRa := math.Sincos(util.Deg2Rad(ra) + harrisPriesterLag)
+	bulge := vecmath.Vec3{X: cosDec * cosRa, Y: cosDec * sinRa, Z: sinDec}
+	cosPsi2 := 0.5 + 0.5*position.Unit().Dot(bulge)
+
+	i := sort.Search(len(harrisPriesterTable), func(i int) bool { return harrisPriesterTable[i][0] > h }) - 1
+	lower, upper := harrisPriesterTable[i], harrisPriesterTable[i+1]
+	scaleMin := (lower[0] - upper[0]) / math.Log(upper[1]/lower[1])
+	scaleMax := (lower[0] - upper[0]) / math.Log(upper[2]/lower[2])
+	densityMin := lower[1] * math.Exp((lower[0]-h)/scaleMin)
+	densityMax := lower[2] * math.Exp((lower[0]-h)/scaleMax)
+
+	// g/km3 to kg/m3
+	return (densityMin + (densityMax-densityMin)*math.Pow(cosPsi2, exponent/2)) * 1e-12
+}
+
+// Default solar activity of the SolarFlux atmosphere
+const (
+	DefaultF107 = 150.0 // 10.7 cm solar radio flux [sfu]
+	DefaultAp   = 15.0  // Geomagnetic index [-]
+)
+
+// SolarFlux is a simple thermospheric model driven by solar activity, used for
+// orbital decay estimates: the exospheric temperature follows the 10.7 cm solar flux
+// and the Ap index, T = 900 + 2.5 (F10.7 - 70) + 1.5 Ap [K], and the density decays
+// exponentially from 6e-10 kg/m3 at 175 km with the scale height T / m [km], where
+// m = 27 - 0.012 (h - 200) is the mean molecular mass. Below 180 km, where the model
+// no longer applies, it joins the static exponential model. The density is zero
+// above 1000 km.
+type SolarFlux struct {
+	F107 float64 // 10.7 cm solar radio flux [sfu], DefaultF107 when zero
+	Ap   float64 // Geomagnetic Ap index, DefaultAp when zero
+}
+
+// Density implements Atmosphere
+func (m SolarFlux) Density(t time.Time, position vecmath.Vec3) float64 {
+	h := height(position)
+	if h >= 1000 {
+		return 0
+	}
+	if h < 180 {
+		return Exponential{}.Density(t, position)
+	}
+	f107, ap := m.F107, m.Ap
+	if f107 <= 0 {
+		f107 = DefaultF107
+	}
+	if ap <= 0 {
+		ap = DefaultAp
+	}
+
+	temperature := 900 + 2.5*(f107-70) + 1.5*ap
+	molecularMass := 27 - 0.012*(h-200)
+	return 6e-10 * math.Exp(-(h-175)/(temperature/molecularMass))
+}
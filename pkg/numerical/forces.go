@@ -0,0 +1,105 @@
+package numerical
+
+import (
+	"time"
+
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// Third-body gravitational parameters [km3/s2]
+const (
+	SunGM  = 1.32712440018e11
+	MoonGM = 4902.800066
+)
+
+// SolarPressure is the solar radiation pressure at one astronomical unit [N/m2]
+const SolarPressure = 4.56e-6
+
+// Spacecraft describes the satellite properties used by the non-gravitational forces
+type Spacecraft struct {
+	AreaToMass              float64 // Cross-section area over mass [m2/kg]
+	DragCoefficient         float64 // Drag coefficient C_D [-]
+	ReflectivityCoefficient float64 // Radiation pressure coefficient C_R [-]
+}
+
+// Default Starlink-like spacecraft properties
+const (
+	DefaultAreaToMass              = 0.02
+	DefaultDragCoefficient         = 2.2
+	DefaultReflectivityCoefficient = 1.3
+)
+
+// Drag is the atmospheric drag on the satellite, with the atmosphere co-rotating with
+// the Earth
+type Drag struct {
+	Atmosphere Atmosphere
+	Spacecraft Spacecraft
+}
+
+// Acceleration implements Force
+func (d Drag) Acceleration(t time.Time, position, velocity vecmath.Vec3) vecmath.Vec3 {
+	density := d.Atmosphere.Density(t, position)
+	if density == 0 {
+		return vecmath.Vec3{}
+	}
+
+	// Velocity relative to the atmosphere, v - omega x r
+	relative := velocity.Add(vecmath.Vec3{
+		X: orbital.EarthRotationRate * position.Y,
+		Y: -orbital.EarthRotationRate * position.X,
+	})
+
+	// rho [kg/m3] * A/m [m2/kg] is per meter; 1000 converts it to per km
+	k := -0.5 * d.Spacecraft.DragCoefficient * d.Spacecraft.AreaToMass * density * 1000
+	return relative.Scale(k * relative.Norm())
+}
+
+// SolarRadiation is the solar radiation pressure on a cannonball satellite, reduced
+// by the visible fraction of the solar disc in Earth's shadow
+type SolarRadiation struct {
+	Spacecraft Spacecraft
+	Shadow     orbital.ShadowModel
+}
+
+// Acceleration implements Force
+func (s SolarRadiation) Acceleration(t time.Time, position, _ vecmath.Vec3) vecmath.Vec3 {
+	sun := orbital.SunPosition(t)
+	fraction := orbital.CalculateIllumination(position, sun, s.Shadow).Fraction
+	if fraction == 0 {
+		return vecmath.Vec3{}
+	}
+
+	// Away from the Sun, scaled by the inverse square of the distance; m/s2 to km/s2
+	away := position.Sub(sun)
+	distance := away.Norm()
+	scale := orbital.AstronomicalUnit / distance
+	k := fraction * SolarPressure * s.Spacecraft.ReflectivityCoefficient * s.Spacecraft.AreaToMass * scale * scale / 1000
+	return away.Scale(k / distance)
+}
+
+// ThirdBody is the perturbation by the gravity of another body, the difference of its
+// attraction on the satellite and on the Earth
+type ThirdBody struct {
+	GM       float64                      // Gravitational parameter of the body [km3/s2]
+	Position func(time.Time) vecmath.Vec3 // Geocentric position of the body in the equatorial frame [km]
+}
+
+// SunGravity returns the third-body perturbation of the Sun
+func SunGravity() ThirdBody {
+	return ThirdBody{GM: SunGM, Position: orbital.SunPosition}
+}
+
+// MoonGravity returns the third-body perturbation of the Moon
+func MoonGravity() ThirdBody {
+	return ThirdBody{GM: MoonGM, Position: orbital.MoonPosition}
+}
+
+// Acceleration implements Force
+func (b ThirdBody) Acceleration(t time.Time, position, _ vecmath.Vec3) vecmath.Vec3 {
+	body := b.Position(t)
+	toBody := body.Sub(position)
+	d := toBody.Norm()
+	s := body.Norm()
+	return toBody.Scale(b.GM / (d * d * d)).Sub(body.Scale(b.GM / (s * s * s)))
+}
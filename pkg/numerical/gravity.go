@@ -0,0 +1,263 @@
+package numerical
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// Earth gravity constants of the EGM96 model
+const (
+	EarthGM     = 398600.4415 // Gravitational parameter [km3/s2]
+	EarthRadius = 6378.1363   // Reference radius of the harmonic coefficients [km]
+)
+
+// Zonal harmonic coefficients J2 to J6 of EGM96 (unnormalized, J_n = -C_n0)
+var zonals = [...]float64{0, 0, 1.08262668355e-3, -2.53265648533e-6, -1.61962159137e-6, -2.27296082869e-7, 5.40681239107e-7}
+
+// MaxZonalDegree is the highest degree of the built-in zonal field
+const MaxZonalDegree = len(zonals) - 1
+
+// GravityField is a spherical-harmonic gravity field with unnormalized coefficients
+type GravityField struct {
+	GM     float64     // Gravitational parameter [km3/s2]
+	Radius float64     // Reference radius [km]
+	Degree int         // Highest degree
+	Order  int         // Highest order; zero for a zonal field
+	C, S   [][]float64 // Coefficients indexed [n][m]
+}
+
+// newGravityField allocates a field of the given degree and order
+func newGravityField(gm, radius float64, degree, order int) *GravityField {
+	f := &GravityField{GM: gm, Radius: radius, Degree: degree, Order: order}
+	f.C = make([][]float64, degree+1)
+	f.S = make([][]float64, degree+1)
+	for n := range f.C {
+		f.C[n] = make([]float64, n+1)
+		f.S[n] = make([]float64, n+1)
+	}
+	f.C[0][0] = 1
+	return f
+}
+
+// ZonalField returns the EGM96 zonal field up to J_degree (2 to 6); degree 0 is the
+// point-mass field
+func ZonalField(degree int) (*GravityField, error) {
+	if degree < 0 || degree == 1 || degree > MaxZonalDegree {
+		return nil, fmt.Errorf("zonal degree %d out of range (0 or 2-%d)", degree, MaxZonalDegree)
+	}
+	f := newGravityField(EarthGM, EarthRadius, degree, 0)
+	for n := 2; n <= degree; n++ {
+		f.C[n][0] = -zonals[n]
+	}
+	return f, nil
+}
+
+// LoadGravityField reads a spherical-harmonic field from a coefficient file truncated to
+// maxDegree. Data lines hold the degree, order, C and S coefficients, optionally after a
+// "gfc" keyword as in ICGEM files; further columns are ignored. The header keywords
+// earth_gravity_constant [m3/s2] and radius [m] override the EGM96 constants, and norm
+// selects fully_normalized (the default) or unnormalized coefficients. Lines starting
+// with '#' are comments.
+func LoadGravityField(path string, maxDegree int) (*GravityField, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gravity field: %w", err)
+	}
+	defer file.Close()
+
+	type coefficient struct {
+		n, m int
+		c, s float64
+	}
+	var coefficients []coefficient
+	gm, radius := EarthGM, EarthRadius
+	normalized := true
+	degree, order := 0, 0
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "earth_gravity_constant", "radius":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%s:%d: missing value", path, line)
+			}
+			value, err := strconv.ParseFloat(strings.Replace(fields[1], "D", "E", 1), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid %s", path, line, fields[0])
+			}
+			if fields[0] == "radius" {
+				radius = value / 1000
+			} else {
+				gm = value / 1e9
+			}
+			continue
+		case "norm":
+			normalized = len(fields) < 2 || fields[1] != "unnormalized"
+			continue
+		case "gfc":
+			fields = fields[1:]
+		}
+
+		if len(fields) < 4 {
+			// Other header lines of ICGEM files
+			continue
+		}
+		n, errN := strconv.Atoi(fields[0])
+		m, errM := strconv.Atoi(fields[1])
+		if errN != nil || errM != nil {
+			continue
+		}
+		c, errC := strconv.ParseFloat(strings.Replace(fields[2], "D", "E", 1), 64)
+		s, errS := strconv.ParseFloat(strings.Replace(fields[3], "D", "E", 1), 64)
+		if errC != nil || errS != nil || m > n || m < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid coefficient", path, line)
+		}
+		if n > maxDegree {
+			continue
+		}
+		coefficients = append(coefficients, coefficient{n, m, c, s})
+		degree = max(degree, n)
+		order = max(order, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read gravity field: %w", err)
+	}
+	if len(coefficients) == 0 {
+		return nil, fmt.Errorf("%s: no coefficients", path)
+	}
+
+	f := newGravityField(gm, radius, degree, order)
+	for _, k := range coefficients {
+		if k.n == 0 {
+			continue // The central term is always 1
+		}
+		scale := 1.0
+		if normalized {
+			scale = normalization(k.n, k.m)
+		}
+		f.C[k.n][k.m] = k.c * scale
+		f.S[k.n][k.m] = k.s * scale
+	}
+	return f, nil
+}
+
+// normalization converts a fully normalized coefficient of degree n and order m to
+// its unnormalized value
+func normalization(n, m int) float64 {
+	delta := 2.0
+	if m == 0 {
+		delta = 1
+	}
+	lnNm, _ := math.Lgamma(float64(n - m + 1))
+	lnNp, _ := math.Lgamma(float64(n + m + 1))
+	return math.Sqrt(delta * float64(2*n+1) * math.Exp(lnNm-lnNp))
+}
+
+// Gravity is the acceleration of a gravity field, including the central term
+type Gravity struct {
+	Field *GravityField
+
+	tables *harmonics // Preallocated by NewGravity, allocated per evaluation otherwise
+}
+
+// NewGravity returns the acceleration of a field with its harmonic tables allocated
+// once. Such a Gravity is not safe for concurrent use; each propagator needs its own.
+func NewGravity(field *GravityField) Gravity {
+	return Gravity{Field: field, tables: newHarmonics(field.Degree + 2)}
+}
+
+// harmonics are the V_nm and W_nm tables of a field evaluation
+type harmonics struct {
+	v, w [][]float64
+}
+
+// newHarmonics allocates tables up to degree and order size - 1
+func newHarmonics(size int) *harmonics {
+	h := &harmonics{v: make([][]float64, size), w: make([][]float64, size)}
+	for n := range h.v {
+		h.v[n] = make([]float64, size)
+		h.w[n] = make([]float64, size)
+	}
+	return h
+}
+
+// Acceleration evaluates the field with the recursion of Cunningham for the
+// harmonics V_nm and W_nm (Montenbruck & Gill, Satellite Orbits, 3.2). Zonal fields are
+// symmetric about the rotation axis and evaluated directly in the equatorial frame;
+// other fields in the Earth-fixed frame.
+func (g Gravity) Acceleration(t time.Time, position, _ vecmath.Vec3) vecmath.Vec3 {
+	f := g.Field
+	r := position
+	if f.Order > 0 {
+		r = orbital.EquatorialToEarthFixed(position, t)
+	}
+
+	// V and W up to one degree and order beyond the field, as the acceleration needs.
+	// Every entry read is written below, except W_00 which stays zero, so reused
+	// tables need no clearing.
+	size := f.Degree + 2
+	tables := g.tables
+	if tables == nil || len(tables.v) != size {
+		tables = newHarmonics(size)
+	}
+	v, w := tables.v, tables.w
+
+	r2 := r.Dot(r)
+	rho := f.Radius * f.Radius / r2
+	x0, y0, z0 := f.Radius*r.X/r2, f.Radius*r.Y/r2, f.Radius*r.Z/r2
+
+	v[0][0] = f.Radius / math.Sqrt(r2)
+	v[1][0] = z0 * v[0][0]
+	for n := 2; n < size; n++ {
+		v[n][0] = (float64(2*n-1)*z0*v[n-1][0] - float64(n-1)*rho*v[n-2][0]) / float64(n)
+	}
+	for m := 1; m < size && m <= f.Order+1; m++ {
+		v[m][m] = float64(2*m-1) * (x0*v[m-1][m-1] - y0*w[m-1][m-1])
+		w[m][m] = float64(2*m-1) * (x0*w[m-1][m-1] + y0*v[m-1][m-1])
+		if m+1 < size {
+			v[m+1][m] = float64(2*m+1) * z0 * v[m][m]
+			w[m+1][m] = float64(2*m+1) * z0 * w[m][m]
+		}
+		for n := m + 2; n < size; n++ {
+			v[n][m] = (float64(2*n-1)*z0*v[n-1][m] - float64(n+m-1)*rho*v[n-2][m]) / float64(n-m)
+			w[n][m] = (float64(2*n-1)*z0*w[n-1][m] - float64(n+m-1)*rho*w[n-2][m]) / float64(n-m)
+		}
+	}
+
+	var a vecmath.Vec3
+	for n := 0; n <= f.Degree; n++ {
+		c := f.C[n][0]
+		a.X -= c * v[n+1][1]
+		a.Y -= c * w[n+1][1]
+		a.Z -= float64(n+1) * c * v[n+1][0]
+
+		for m := 1; m <= n && m <= f.Order; m++ {
+			c, s := f.C[n][m], f.S[n][m]
+			fac := 0.5 * float64((n-m+1)*(n-m+2))
+			a.X += 0.5*(-c*v[n+1][m+1]-s*w[n+1][m+1]) + fac*(c*v[n+1][m-1]+s*w[n+1][m-1])
+			a.Y += 0.5*(-c*w[n+1][m+1]+s*v[n+1][m+1]) + fac*(-c*w[n+1][m-1]+s*v[n+1][m-1])
+			a.Z += float64(n-m+1) * (-c*v[n+1][m] - s*w[n+1][m])
+		}
+	}
+	a = a.Scale(f.GM / (f.Radius * f.Radius))
+
+	if f.Order > 0 {
+		a = orbital.EarthFixedToEquatorial(a, t)
+	}
+	return a
+}
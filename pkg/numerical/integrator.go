@@ -0,0 +1,64 @@
+package numerical
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/vecmath"
+)
+
+// Dormand-Prince 5(4) tableau: nodes, stage coefficients, fifth-order weights and the
+// difference between the fifth- and embedded fourth-order weights
+var (
+	dpC = [7]float64{0, 1.0 / 5, 3.0 / 10, 4.0 / 5, 8.0 / 9, 1, 1}
+	dpA = [7][6]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	dpB = [7]float64{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84, 0}
+	dpE = [7]float64{71.0 / 57600, 0, -71.0 / 16695, 71.0 / 1920, -17253.0 / 339200, 22.0 / 525, -1.0 / 40}
+)
+
+// dormandPrince takes one step of h seconds (negative to integrate backward) and
+// returns the new position and velocity with the error of the step relative to the
+// tolerance: the step is acceptable when the error is at most 1
+func dormandPrince(forces []Force, t time.Time, r, v vecmath.Vec3, h, tolerance float64) (vecmath.Vec3, vecmath.Vec3, float64) {
+	var kr, kv [7]vecmath.Vec3 // Stage derivatives of position and velocity
+
+	for stage := 0; stage < 7; stage++ {
+		ri, vi := r, v
+		for j := 0; j < stage; j++ {
+			if a := dpA[stage][j]; a != 0 {
+				ri = ri.Add(kr[j].Scale(h * a))
+				vi = vi.Add(kv[j].Scale(h * a))
+			}
+		}
+		ti := t.Add(time.Duration(dpC[stage] * h * float64(time.Second)))
+		kr[stage] = vi
+		kv[stage] = acceleration(forces, ti, ri, vi)
+	}
+
+	rNew, vNew := r, v
+	var er, ev vecmath.Vec3
+	for stage := 0; stage < 7; stage++ {
+		rNew = rNew.Add(kr[stage].Scale(h * dpB[stage]))
+		vNew = vNew.Add(kv[stage].Scale(h * dpB[stage]))
+		er = er.Add(kr[stage].Scale(h * dpE[stage]))
+		ev = ev.Add(kv[stage].Scale(h * dpE[stage]))
+	}
+
+	// Root-mean-square error scaled component-wise by the tolerance
+	scaled := func(e, y0, y1 float64) float64 {
+		s := e / (tolerance * (1 + math.Max(math.Abs(y0), math.Abs(y1))))
+		return s * s
+	}
+	sum := scaled(er.X, r.X, rNew.X) + scaled(er.Y, r.Y, rNew.Y) + scaled(er.Z, r.Z, rNew.Z) +
+		scaled(ev.X, v.X, vNew.X) + scaled(ev.Y, v.Y, vNew.Y) + scaled(ev.Z, v.Z, vNew.Z)
+
+	return rNew, vNew, math.Sqrt(sum / 6)
+}
@@ -0,0 +1,83 @@
+package numerical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"starlink/pkg/orbital"
+)
+
+// DefaultForces is the force model specification used when none is given
+const DefaultForces = "j6,drag,srp,sun,moon"
+
+// ForceModel selects the forces of a numerical propagation
+type ForceModel struct {
+	Gravity        *GravityField       // Earth gravity field, a point mass when nil
+	Atmosphere     Atmosphere          // Atmosphere for drag, no drag when nil
+	SolarRadiation bool                // Solar radiation pressure
+	Shadow         orbital.ShadowModel // Shadow model of the solar radiation pressure
+	Sun            bool                // Third-body gravity of the Sun
+	Moon           bool                // Third-body gravity of the Moon
+	Spacecraft     Spacecraft          // Properties for drag and radiation pressure
+}
+
+// ParseForceModel parses a comma-separated list of forces: j2 to j6 for the zonal
+// harmonics up to that degree, drag (Harris-Priester atmosphere), srp, sun and moon.
+// Earth gravity is a point mass when no zonal degree is given. The spacecraft gets the
+// default properties.
+func ParseForceModel(spec string) (ForceModel, error) {
+	m := ForceModel{Spacecraft: Spacecraft{
+		AreaToMass:              DefaultAreaToMass,
+		DragCoefficient:         DefaultDragCoefficient,
+		ReflectivityCoefficient: DefaultReflectivityCoefficient,
+	}}
+
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+		case "drag":
+			m.Atmosphere = HarrisPriester{}
+		case "srp":
+			m.SolarRadiation = true
+		case "sun":
+			m.Sun = true
+		case "moon":
+			m.Moon = true
+		default:
+			degree, err := strconv.Atoi(strings.TrimPrefix(name, "j"))
+			if !strings.HasPrefix(name, "j") || err != nil {
+				return m, fmt.Errorf("unknown force %q (expected j2-j%d, drag, srp, sun or moon)", name, MaxZonalDegree)
+			}
+			if m.Gravity, err = ZonalField(degree); err != nil {
+				return m, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// Forces returns the forces of the model for one propagator, as the gravity keeps
+// its harmonic tables between evaluations
+func (m ForceModel) Forces() []Force {
+	field := m.Gravity
+	if field == nil {
+		field, _ = ZonalField(0)
+	}
+	forces := []Force{NewGravity(field)}
+
+	if m.Atmosphere != nil {
+		forces = append(forces, Drag{Atmosphere: m.Atmosphere, Spacecraft: m.Spacecraft})
+	}
+	if m.SolarRadiation {
+		forces = append(forces, SolarRadiation{Spacecraft: m.Spacecraft, Shadow: m.Shadow})
+	}
+	if m.Sun {
+		forces = append(forces, SunGravity())
+	}
+	if m.Moon {
+		forces = append(forces, MoonGravity())
+	}
+	return forces
+}
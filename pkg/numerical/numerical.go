@@ -0,0 +1,178 @@
+// Package numerical propagates orbits by integrating the equations of motion with an
+// adaptive Dormand-Prince 5(4) integrator under configurable force models: Earth
+// gravity from zonal harmonics or a spherical-harmonic field, atmospheric drag, solar
+// radiation pressure and Sun/Moon third-body gravity.
+package numerical
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// Default integration settings
+const (
+	DefaultTolerance = 1e-10            // Relative error per step
+	DefaultMaxStep   = 5 * time.Minute  // Largest step
+	DefaultMinStep   = time.Millisecond // Smallest step before the integration fails
+)
+
+// MinRadius is the geocentric distance below which the orbit is considered to have
+// re-entered and the integration stops [km]
+const MinRadius = 6378.137 + 80
+
+// Force is a perturbing or central acceleration acting on the satellite
+type Force interface {
+	// Acceleration returns the acceleration [km/s2] at time t for the position [km] and
+	// velocity [km/s] in the equatorial frame
+	Acceleration(t time.Time, position, velocity vecmath.Vec3) vecmath.Vec3
+}
+
+// Options controls the integrator
+type Options struct {
+	Tolerance float64       // Relative error per step, DefaultTolerance when zero
+	MaxStep   time.Duration // Largest step, DefaultMaxStep when zero
+	MinStep   time.Duration // Smallest step, DefaultMinStep when zero
+}
+
+// Propagator integrates a state under a set of forces. It keeps the last computed
+// state, so requests in time order (forward or backward) continue the integration
+// instead of starting over. A Propagator is not safe for concurrent use.
+type Propagator struct {
+	forces []Force
+	opts   Options
+
+	t    time.Time    // Time of the current state
+	r, v vecmath.Vec3 // Current position [km] and velocity [km/s]
+	step float64      // Size of the next step [s], unsigned
+	err  error        // First integration failure
+}
+
+// NewPropagator starts a numerical propagation from an equatorial state vector
+func NewPropagator(initial model.StateVector, forces []Force, opts Options) *Propagator {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	if opts.MaxStep <= 0 {
+		opts.MaxStep = DefaultMaxStep
+	}
+	if opts.MinStep <= 0 {
+		opts.MinStep = DefaultMinStep
+	}
+	return &Propagator{
+		forces: forces,
+		opts:   opts,
+		t:      initial.Time,
+		r:      vecmath.Vec3{X: initial.X, Y: initial.Y, Z: initial.Z},
+		v:      vecmath.Vec3{X: initial.VX, Y: initial.VY, Z: initial.VZ},
+		step:   10,
+	}
+}
+
+// FromElements starts a numerical propagation from the state of an element set at
+// start, as given by the analytic propagator
+func FromElements(sat *model.TleOrbitalElement, start time.Time, forces []Force, opts Options) (*Propagator, error) {
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		return nil, err
+	}
+	return NewPropagator(p.StateECI(start), forces, opts), nil
+}
+
+// StateECI returns the state in the equatorial frame at targetTime. When the
+// integration fails the last state reached is returned and Err reports the failure.
+func (p *Propagator) StateECI(targetTime time.Time) model.StateVector {
+	if p.err == nil {
+		p.err = p.advance(targetTime)
+	}
+	return model.StateVector{
+		Time: p.t.UTC(),
+		X:    p.r.X,
+		Y:    p.r.Y,
+		Z:    p.r.Z,
+		VX:   p.v.X,
+		VY:   p.v.Y,
+		VZ:   p.v.Z,
+	}
+}
+
+// PositionECI returns the position in the equatorial frame at targetTime [km]
+func (p *Propagator) PositionECI(targetTime time.Time) vecmath.Vec3 {
+	p.StateECI(targetTime)
+	return p.r
+}
+
+// Err returns the first integration failure, if any
+func (p *Propagator) Err() error {
+	return p.err
+}
+
+// ErrReentry is reported by Err when the orbit decays below MinRadius
+var ErrReentry = errors.New("satellite re-entered the atmosphere")
+
+// advance integrates from the current state to targetTime, shortening the last step
+// to land on it exactly
+func (p *Propagator) advance(targetTime time.Time) error {
+	maxStep := p.opts.MaxStep.Seconds()
+	minStep := p.opts.MinStep.Seconds()
+
+	for {
+		remaining := targetTime.Sub(p.t).Seconds()
+		if math.Abs(remaining) < 1e-9 {
+			return nil
+		}
+		direction := math.Copysign(1, remaining)
+
+		h := math.Min(p.step, maxStep)
+		last := false
+		if h >= math.Abs(remaining) {
+			h, last = math.Abs(remaining), true
+		}
+
+		r, v, errNorm := dormandPrince(p.forces, p.t, p.r, p.v, direction*h, p.opts.Tolerance)
+
+		// Step size control with the usual safety factor and growth limits
+		factor := 5.0
+		if errNorm > 0 {
+			factor = math.Max(0.2, math.Min(5, 0.9*math.Pow(errNorm, -0.2)))
+		}
+		if errNorm > 1 || math.IsNaN(errNorm) {
+			if math.IsNaN(errNorm) {
+				factor = 0.2
+			}
+			p.step = h * factor
+			if p.step < minStep {
+				return fmt.Errorf("integration step below %v at %s", p.opts.MinStep, p.t.Format(time.RFC3339))
+			}
+			continue
+		}
+
+		if last {
+			p.t = targetTime
+		} else {
+			p.t = p.t.Add(time.Duration(direction * h * float64(time.Second)))
+		}
+		p.r, p.v = r, v
+		if !last || factor < 1 {
+			p.step = h * factor
+		}
+
+		if p.r.Norm() < MinRadius {
+			return ErrReentry
+		}
+	}
+}
+
+// acceleration sums the forces at a state
+func acceleration(forces []Force, t time.Time, r, v vecmath.Vec3) vecmath.Vec3 {
+	var a vecmath.Vec3
+	for _, f := range forces {
+		a = a.Add(f.Acceleration(t, r, v))
+	}
+	return a
+}
@@ -0,0 +1,83 @@
+package numerical_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+	"starlink/pkg/vecmath"
+)
+
+var testEpoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// position and velocity of a state vector
+func vectors(s model.StateVector) (vecmath.Vec3, vecmath.Vec3) {
+	return vecmath.Vec3{X: s.X, Y: s.Y, Z: s.Z}, vecmath.Vec3{X: s.VX, Y: s.VY, Z: s.VZ}
+}
+
+// TestTwoBodyEnergyAndPeriod propagates an eccentric orbit in the point-mass field for
+// ten days and checks that the energy is conserved and the state repeats every period
+func TestTwoBodyEnergyAndPeriod(t *testing.T) {
+	field, err := numerical.ZonalField(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := model.StateVector{Time: testEpoch, X: 7000, VY: 7.9, VZ: 1.2}
+	p := numerical.NewPropagator(initial, []numerical.Force{numerical.NewGravity(field)}, numerical.Options{})
+
+	r0, v0 := vectors(initial)
+	energy := func(r, v vecmath.Vec3) float64 { return v.Dot(v)/2 - numerical.EarthGM/r.Norm() }
+	e0 := energy(r0, v0)
+	a := -numerical.EarthGM / (2 * e0)
+	period := time.Duration(2 * math.Pi * math.Sqrt(a*a*a/numerical.EarthGM) * float64(time.Second))
+
+	for k := 1; k <= 3; k++ {
+		r, _ := vectors(p.StateECI(testEpoch.Add(time.Duration(k) * period)))
+		if d := r.Sub(r0).Norm(); d > 1e-3 {
+			t.Errorf("position after %d periods is %.6f km from the initial one", k, d)
+		}
+	}
+
+	end := testEpoch.Add(10 * 24 * time.Hour)
+	if e := energy(vectors(p.StateECI(end))); math.Abs(e/e0-1) > 1e-6 {
+		t.Errorf("energy drifted by %.2e over 10 days", e/e0-1)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestJ2RaanRate propagates a circular orbit in the J2 field for ten days and compares
+// the regression of the node with the secular rate -3/2 n J2 (R/a)^2 cos i
+func TestJ2RaanRate(t *testing.T) {
+	const j2 = 1.08262668355e-3
+	field, err := numerical.ZonalField(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := numerical.EarthRadius + 550
+	inclination := 53 * math.Pi / 180
+	speed := math.Sqrt(numerical.EarthGM / a)
+	initial := model.StateVector{Time: testEpoch, X: a,
+		VY: speed * math.Cos(inclination), VZ: speed * math.Sin(inclination)}
+	p := numerical.NewPropagator(initial, []numerical.Force{numerical.NewGravity(field)}, numerical.Options{})
+
+	raan := func(s model.StateVector) float64 {
+		r, v := vectors(s)
+		h := r.Cross(v)
+		return math.Atan2(h.X, -h.Y)
+	}
+	const days = 10
+	drift := math.Remainder(raan(p.StateECI(testEpoch.Add(days*24*time.Hour)))-raan(initial), 2*math.Pi)
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	n := math.Sqrt(numerical.EarthGM / (a * a * a))
+	want := -1.5 * n * j2 * math.Pow(numerical.EarthRadius/a, 2) * math.Cos(inclination) * days * 86400
+	if math.Abs(drift/want-1) > 0.01 {
+		t.Errorf("RAAN drift %.3f° over %d days, want %.3f°", drift*180/math.Pi, days, want*180/math.Pi)
+	}
+}
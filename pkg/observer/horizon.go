@@ -0,0 +1,123 @@
+package observer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"starlink/pkg/model"
+)
+
+// LoadHorizonMask reads a horizon mask from a CSV file
+func LoadHorizonMask(path string) (*model.HorizonMask, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open horizon mask: %w", err)
+	}
+	defer file.Close()
+
+	return ParseHorizonMask(file)
+}
+
+// ParseHorizonMask parses a horizon mask given as CSV rows of "azimuth,min elevation"
+// in degrees. Blank lines, lines starting with # and a non-numeric header row are
+// ignored. Rows may be in any order.
+func ParseHorizonMask(r io.Reader) (*model.HorizonMask, error) {
+	type point struct{ az, el float64 }
+	var points []point
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("horizon mask line %d: expected azimuth,elevation", lineNo)
+		}
+		az, errAz := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		el, errEl := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if errAz != nil || errEl != nil {
+			if len(points) == 0 && lineNo == 1 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("horizon mask line %d: invalid number in %q", lineNo, line)
+		}
+		if el < -90 || el > 90 {
+			return nil, fmt.Errorf("horizon mask line %d: elevation %.3f out of range", lineNo, el)
+		}
+
+		// Normalise the azimuth into [0, 360)
+		for az < 0 {
+			az += 360.0
+		}
+		for az >= 360.0 {
+			az -= 360.0
+		}
+		points = append(points, point{az, el})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("horizon mask has no points")
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].az < points[j].az })
+
+	mask := &model.HorizonMask{}
+	for i, p := range points {
+		if i > 0 && p.az == points[i-1].az {
+			return nil, fmt.Errorf("horizon mask has duplicate azimuth %.3f", p.az)
+		}
+		mask.Azimuths = append(mask.Azimuths, p.az)
+		mask.Elevations = append(mask.Elevations, p.el)
+	}
+
+	return mask, nil
+}
+
+// MaskElevationAt returns the minimum elevation of a horizon mask at an azimuth
+// [degree], linearly interpolated between the mask points and wrapping around north
+func MaskElevationAt(m *model.HorizonMask, azimuth float64) float64 {
+	n := len(m.Azimuths)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 {
+		return m.Elevations[0]
+	}
+
+	azimuth = math.Mod(azimuth, 360.0)
+	if azimuth < 0 {
+		azimuth += 360.0
+	}
+
+	// Find the segment containing the azimuth; the last segment wraps to the first point
+	i := sort.SearchFloat64s(m.Azimuths, azimuth)
+	var az0, el0, az1, el1 float64
+	switch {
+	case i < n && m.Azimuths[i] == azimuth:
+		return m.Elevations[i]
+	case i == 0:
+		az0, el0 = m.Azimuths[n-1]-360.0, m.Elevations[n-1]
+		az1, el1 = m.Azimuths[0], m.Elevations[0]
+	case i == n:
+		az0, el0 = m.Azimuths[n-1], m.Elevations[n-1]
+		az1, el1 = m.Azimuths[0]+360.0, m.Elevations[0]
+	default:
+		az0, el0 = m.Azimuths[i-1], m.Elevations[i-1]
+		az1, el1 = m.Azimuths[i], m.Elevations[i]
+	}
+
+	return el0 + (el1-el0)*(azimuth-az0)/(az1-az0)
+}
@@ -0,0 +1,140 @@
+package observer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// Station is an observer prepared for repeated look-angle evaluation. Its
+// Earth-fixed position and local East-North-Up rotation are computed once.
+type Station struct {
+	Observer model.Observer
+	position vecmath.Vec3 // Earth-fixed position [km]
+	enu      vecmath.Mat3 // Rotation from Earth-fixed to East-North-Up
+}
+
+// NewStation prepares an observer for look-angle evaluation
+func NewStation(obs model.Observer) *Station {
+	sinLat, cosLat := math.Sincos(util.Deg2Rad(obs.Lat))
+	sinLng, cosLng := math.Sincos(util.Deg2Rad(obs.Lng))
+
+	return &Station{
+		Observer: obs,
+		position: orbital.GeodeticToEarthFixed(obs.Lat, obs.Lng, obs.Alt),
+		// Rows are the East, North and Up unit vectors in Earth-fixed coordinates
+		enu: vecmath.Mat3{
+			-sinLng, cosLng, 0,
+			-sinLat * cosLng, -sinLat * sinLng, cosLat,
+			cosLat * cosLng, cosLat * sinLng, sinLat},
+	}
+}
+
+// Position returns the Earth-fixed position of the station [km]
+func (s *Station) Position() vecmath.Vec3 {
+	return s.position
+}
+
+// PositionECI returns the position of the station in the equatorial frame at targetTime [km]
+func (s *Station) PositionECI(targetTime time.Time) vecmath.Vec3 {
+	return orbital.EarthFixedToEquatorial(s.position, targetTime)
+}
+
+// Topocentric returns the topocentric right ascension [degree], declination [degree]
+// and range [km] of an equatorial position seen from the station at targetTime
+func (s *Station) Topocentric(position vecmath.Vec3, targetTime time.Time) (float64, float64, float64) {
+	return orbital.RightAscensionDeclination(position.Sub(s.PositionECI(targetTime)))
+}
+
+// Look returns the look angles from the station to the satellite at targetTime
+func (s *Station) Look(p *orbital.Propagator, targetTime time.Time) model.LookAngle {
+	state := p.StateECEF(targetTime)
+	return s.LookAt(state)
+}
+
+// LookAt returns the look angles to a satellite whose Earth-fixed state is known
+func (s *Station) LookAt(state model.StateVector) model.LookAngle {
+	// The station is fixed in the Earth-fixed frame, so the relative velocity is
+	// the satellite's Earth-fixed velocity
+	rho := vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}.Sub(s.position)
+	velocity := vecmath.Vec3{X: state.VX, Y: state.VY, Z: state.VZ}
+	azimuth, elevation, slantRange := s.direction(rho)
+
+	return model.LookAngle{
+		Time:      state.Time,
+		Azimuth:   azimuth,
+		Elevation: elevation,
+		Range:     slantRange,
+		RangeRate: rho.Dot(velocity) / slantRange,
+		Visible:   elevation > s.HorizonAt(azimuth),
+	}
+}
+
+// direction returns the azimuth [degree], elevation [degree] and length [km] of an
+// Earth-fixed vector from the station
+func (s *Station) direction(rho vecmath.Vec3) (float64, float64, float64) {
+	slantRange := rho.Norm()
+
+	local := s.enu.MulVec(rho)
+	azimuth := util.Rad2Deg(math.Atan2(local.X, local.Y))
+	if azimuth < 0 {
+		azimuth += 360.0
+	}
+
+	elevation := util.Rad2Deg(math.Asin(local.Z / slantRange))
+
+	return azimuth, elevation, slantRange
+}
+
+// HorizonAt returns the elevation of the observer's horizon at an azimuth [degree]:
+// the horizon mask when one is set, otherwise the geometric horizon (0)
+func (s *Station) HorizonAt(azimuth float64) float64 {
+	if s.Observer.Mask == nil {
+		return 0
+	}
+	return MaskElevationAt(s.Observer.Mask, azimuth)
+}
+
+// LookAngles returns the look angles from an observer to a satellite at targetTime
+func LookAngles(sat *model.TleOrbitalElement, obs model.Observer, targetTime time.Time) (*model.LookAngle, error) {
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		return nil, err
+	}
+	look := NewStation(obs).Look(p, targetTime)
+	return &look, nil
+}
+
+// ParseObserver parses an observer given as "lat,lon,alt" with latitude and
+// longitude in degrees and height in km; the height may be omitted
+func ParseObserver(value string) (model.Observer, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return model.Observer{}, fmt.Errorf("invalid observer %q (expected lat,lon[,alt])", value)
+	}
+
+	var coords [3]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return model.Observer{}, fmt.Errorf("invalid observer %q: %w", value, err)
+		}
+		coords[i] = v
+	}
+
+	if coords[0] < -90 || coords[0] > 90 {
+		return model.Observer{}, fmt.Errorf("observer latitude %.6f out of range", coords[0])
+	}
+	if coords[1] < -180 || coords[1] > 360 {
+		return model.Observer{}, fmt.Errorf("observer longitude %.6f out of range", coords[1])
+	}
+
+	return model.Observer{Lat: coords[0], Lng: coords[1], Alt: coords[2]}, nil
+}
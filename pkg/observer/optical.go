@@ -0,0 +1,135 @@
+package observer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"starlink/pkg/orbital"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// Twilight selects how dark the observer's sky must be for a sunlit satellite to be
+// optically visible
+type Twilight int
+
+const (
+	// TwilightCivil requires the Sun at least 6 degrees below the horizon
+	TwilightCivil Twilight = iota
+	// TwilightNautical requires the Sun at least 12 degrees below the horizon
+	TwilightNautical
+	// TwilightAstronomical requires the Sun at least 18 degrees below the horizon
+	TwilightAstronomical
+)
+
+// SunAltitude returns the Sun altitude at which the twilight begins [degree]
+func (tw Twilight) SunAltitude() float64 {
+	switch tw {
+	case TwilightNautical:
+		return -12.0
+	case TwilightAstronomical:
+		return -18.0
+	default:
+		return -6.0
+	}
+}
+
+// String returns the name of the twilight as accepted by ParseTwilight
+func (tw Twilight) String() string {
+	switch tw {
+	case TwilightNautical:
+		return "nautical"
+	case TwilightAstronomical:
+		return "astronomical"
+	default:
+		return "civil"
+	}
+}
+
+// ParseTwilight parses a twilight name (civil, nautical or astronomical)
+func ParseTwilight(name string) (Twilight, error) {
+	switch strings.ToLower(name) {
+	case "civil":
+		return TwilightCivil, nil
+	case "nautical":
+		return TwilightNautical, nil
+	case "astronomical":
+		return TwilightAstronomical, nil
+	default:
+		return 0, fmt.Errorf("unknown twilight %q (expected civil, nautical or astronomical)", name)
+	}
+}
+
+// Visibility is the optical visibility of a satellite from the station
+type Visibility int
+
+const (
+	// BelowHorizon means the satellite is below the observer's horizon mask
+	BelowHorizon Visibility = iota
+	// Daylight means the sky is too bright: the Sun is above the twilight altitude
+	Daylight
+	// Eclipsed means the sky is dark but the satellite is in Earth's umbra
+	Eclipsed
+	// Visible means the satellite is sunlit, above the horizon and the sky is dark
+	Visible
+)
+
+// String returns the name of the visibility class
+func (v Visibility) String() string {
+	switch v {
+	case Daylight:
+		return "daylight"
+	case Eclipsed:
+		return "eclipsed"
+	case Visible:
+		return "visible"
+	default:
+		return "below horizon"
+	}
+}
+
+// SunElevation returns the geometric elevation of the Sun's centre seen from the
+// station at targetTime, without refraction [degree]
+func (s *Station) SunElevation(targetTime time.Time) float64 {
+	return s.sunElevation(orbital.SunPosition(targetTime), targetTime)
+}
+
+// sunElevation returns the Sun elevation given its equatorial position [degree]
+func (s *Station) sunElevation(sun vecmath.Vec3, targetTime time.Time) float64 {
+	_, elevation, _ := s.direction(orbital.EquatorialToEarthFixed(sun, targetTime).Sub(s.position))
+	return elevation
+}
+
+// Visibility classifies the optical visibility of the satellite at targetTime.
+// A satellite in penumbra still reflects sunlight and counts as sunlit.
+func (s *Station) Visibility(p *orbital.Propagator, targetTime time.Time, twilight Twilight) Visibility {
+	switch {
+	case !s.Look(p, targetTime).Visible:
+		return BelowHorizon
+	case s.SunElevation(targetTime) > twilight.SunAltitude():
+		return Daylight
+	case p.Illumination(targetTime, orbital.ShadowConical).State == orbital.Umbra:
+		return Eclipsed
+	default:
+		return Visible
+	}
+}
+
+// OpticalMargin returns a function of time that is positive while the satellite is
+// sunlit and the observer's sky is dark, and changes sign continuously at either
+// boundary. The horizon is not taken into account.
+func (s *Station) OpticalMargin(p *orbital.Propagator, twilight Twilight) func(time.Time) float64 {
+	return func(t time.Time) float64 {
+		sun := orbital.SunPosition(t)
+
+		// Degrees of Sun depression beyond the twilight altitude
+		darkness := twilight.SunAltitude() - s.sunElevation(sun, t)
+
+		// Angular distance outside the umbra boundary [degree]
+		_, umbra := orbital.ShadowBoundaries(p.PositionECI(t), sun, orbital.ShadowConical)
+
+		return math.Min(darkness, util.Rad2Deg(umbra))
+	}
+}
@@ -0,0 +1,219 @@
+package orbital
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/kepler"
+	"starlink/pkg/model"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// EarthMu is the gravitational parameter of the Earth consistent with the semi-major
+// axis derived from the mean motion [km3/s2]
+const EarthMu = 2.975537e15 / (86400.0 * 86400.0)
+
+// singularTolerance is the eccentricity or inclination [Rad] below which the argument
+// of perigee or the ascending node is undefined and set to zero
+const singularTolerance = 1e-11
+
+// KeplerianElements are classical osculating orbital elements
+type KeplerianElements struct {
+	SemiMajorAxis     float64 // a [km]
+	Eccentricity      float64 // e [-]
+	Inclination       float64 // i [Degree]
+	Raan              float64 // Right ascension of the ascending node Ω [Degree]
+	ArgumentOfPerigee float64 // ω [Degree], zero for circular orbits
+	TrueAnomaly       float64 // ν [Degree], the argument of latitude for circular orbits
+}
+
+// EquinoctialElements are orbital elements that remain defined for circular and
+// equatorial orbits (Broucke and Cefola, prograde form)
+type EquinoctialElements struct {
+	SemiMajorAxis float64 // a [km]
+	H             float64 // e sin(ω + Ω) [-]
+	K             float64 // e cos(ω + Ω) [-]
+	P             float64 // tan(i/2) sin Ω [-]
+	Q             float64 // tan(i/2) cos Ω [-]
+	MeanLongitude float64 // λ = M + ω + Ω [Degree]
+}
+
+// OrbitProperties are quantities derived from the osculating elements
+type OrbitProperties struct {
+	PerigeeAltitude float64       // Perigee altitude above the propagator's spherical Earth [km]
+	ApogeeAltitude  float64       // Apogee altitude above the propagator's spherical Earth [km]
+	Period          time.Duration // Orbital period
+	MeanMotion      float64       // Mean motion [Rev/Day]
+	SpecificEnergy  float64       // Specific orbital energy [km2/s2]
+	AngularMomentum float64       // Magnitude of the specific angular momentum [km2/s]
+}
+
+// StateToKeplerian converts an equatorial state vector to osculating Keplerian elements
+func StateToKeplerian(state model.StateVector) KeplerianElements {
+	r := vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}
+	v := vecmath.Vec3{X: state.VX, Y: state.VY, Z: state.VZ}
+	radius := r.Norm()
+
+	h := r.Cross(v)
+	node := vecmath.Vec3{X: -h.Y, Y: h.X}
+	eVec := v.Cross(h).Scale(1 / EarthMu).Sub(r.Scale(1 / radius))
+	energy := v.Dot(v)/2 - EarthMu/radius
+
+	el := KeplerianElements{
+		SemiMajorAxis: -EarthMu / (2 * energy),
+		Eccentricity:  eVec.Norm(),
+	}
+	inclination := math.Acos(clamp(h.Z / h.Norm()))
+	el.Inclination = util.Rad2Deg(inclination)
+
+	// Angles measured from the node, or from the x axis for equatorial orbits
+	equatorial := inclination < singularTolerance || math.Pi-inclination < singularTolerance
+	circular := el.Eccentricity < singularTolerance
+	reference := node.Unit()
+	if equatorial {
+		reference = vecmath.Vec3{X: 1}
+	} else {
+		el.Raan = degrees(math.Atan2(node.Y, node.X))
+	}
+
+	// Angles in the orbital plane are measured in the direction of motion
+	inPlane := func(from, to vecmath.Vec3) float64 {
+		return degrees(math.Atan2(from.Cross(to).Dot(h.Unit()), from.Dot(to)))
+	}
+	if circular {
+		el.TrueAnomaly = inPlane(reference, r)
+	} else {
+		el.ArgumentOfPerigee = inPlane(reference, eVec)
+		el.TrueAnomaly = inPlane(eVec, r)
+	}
+
+	return el
+}
+
+// KeplerianToState converts osculating Keplerian elements to an equatorial state
+// vector at time t
+func KeplerianToState(el KeplerianElements, t time.Time) model.StateVector {
+	nu := util.Deg2Rad(el.TrueAnomaly)
+	p := el.SemiMajorAxis * (1 - el.Eccentricity*el.Eccentricity)
+	sinNu, cosNu := math.Sincos(nu)
+	radius := p / (1 + el.Eccentricity*cosNu)
+
+	// Perifocal position and velocity
+	position := vecmath.Vec3{X: radius * cosNu, Y: radius * sinNu}
+	speed := math.Sqrt(EarthMu / p)
+	velocity := vecmath.Vec3{X: -speed * sinNu, Y: speed * (el.Eccentricity + cosNu)}
+
+	rotation := vecmath.RotZ(util.Deg2Rad(el.Raan)).
+		Mul(vecmath.RotX(util.Deg2Rad(el.Inclination))).
+		Mul(vecmath.RotZ(util.Deg2Rad(el.ArgumentOfPerigee)))
+	return stateVector(t, rotation.MulVec(position), rotation.MulVec(velocity))
+}
+
+// KeplerianToEquinoctial converts Keplerian to equinoctial elements
+func KeplerianToEquinoctial(el KeplerianElements) EquinoctialElements {
+	raan := util.Deg2Rad(el.Raan)
+	longitudeOfPerigee := raan + util.Deg2Rad(el.ArgumentOfPerigee)
+	tanHalfI := math.Tan(util.Deg2Rad(el.Inclination) / 2)
+
+	return EquinoctialElements{
+		SemiMajorAxis: el.SemiMajorAxis,
+		H:             el.Eccentricity * math.Sin(longitudeOfPerigee),
+		K:             el.Eccentricity * math.Cos(longitudeOfPerigee),
+		P:             tanHalfI * math.Sin(raan),
+		Q:             tanHalfI * math.Cos(raan),
+		MeanLongitude: degrees(longitudeOfPerigee + util.Deg2Rad(el.MeanAnomaly())),
+	}
+}
+
+// EquinoctialToKeplerian converts equinoctial to Keplerian elements
+func EquinoctialToKeplerian(eq EquinoctialElements) KeplerianElements {
+	el := KeplerianElements{
+		SemiMajorAxis: eq.SemiMajorAxis,
+		Eccentricity:  math.Hypot(eq.H, eq.K),
+		Inclination:   util.Rad2Deg(2 * math.Atan(math.Hypot(eq.P, eq.Q))),
+	}
+	raan := 0.0
+	if math.Hypot(eq.P, eq.Q) >= singularTolerance {
+		raan = math.Atan2(eq.P, eq.Q)
+	}
+	longitudeOfPerigee := raan
+	if el.Eccentricity >= singularTolerance {
+		longitudeOfPerigee = math.Atan2(eq.H, eq.K)
+	}
+	el.Raan = degrees(raan)
+	el.ArgumentOfPerigee = degrees(longitudeOfPerigee - raan)
+
+	meanAnomaly := util.Deg2Rad(eq.MeanLongitude) - longitudeOfPerigee
+	el.TrueAnomaly = degrees(trueAnomaly(el.Eccentricity, meanAnomaly))
+	return el
+}
+
+// StateToEquinoctial converts an equatorial state vector to equinoctial elements
+func StateToEquinoctial(state model.StateVector) EquinoctialElements {
+	return KeplerianToEquinoctial(StateToKeplerian(state))
+}
+
+// EquinoctialToState converts equinoctial elements to an equatorial state vector at time t
+func EquinoctialToState(eq EquinoctialElements, t time.Time) model.StateVector {
+	return KeplerianToState(EquinoctialToKeplerian(eq), t)
+}
+
+// EccentricAnomaly returns the eccentric anomaly [Degree]
+func (el KeplerianElements) EccentricAnomaly() float64 {
+	sinNu, cosNu := math.Sincos(util.Deg2Rad(el.TrueAnomaly))
+	e := el.Eccentricity
+	return degrees(math.Atan2(math.Sqrt(1-e*e)*sinNu, e+cosNu))
+}
+
+// MeanAnomaly returns the mean anomaly [Degree]
+func (el KeplerianElements) MeanAnomaly() float64 {
+	eccentric := util.Deg2Rad(el.EccentricAnomaly())
+	return degrees(eccentric - el.Eccentricity*math.Sin(eccentric))
+}
+
+// ArgumentOfLatitude returns the angle from the ascending node to the satellite [Degree]
+func (el KeplerianElements) ArgumentOfLatitude() float64 {
+	return degrees(util.Deg2Rad(el.ArgumentOfPerigee + el.TrueAnomaly))
+}
+
+// Properties returns the quantities derived from the elements
+func (el KeplerianElements) Properties() OrbitProperties {
+	a, e := el.SemiMajorAxis, el.Eccentricity
+	periodSeconds := 2 * math.Pi * math.Sqrt(a*a*a/EarthMu)
+	return OrbitProperties{
+		PerigeeAltitude: a*(1-e) - util.EarthRadius,
+		ApogeeAltitude:  a*(1+e) - util.EarthRadius,
+		Period:          time.Duration(periodSeconds * float64(time.Second)),
+		MeanMotion:      86400 / periodSeconds,
+		SpecificEnergy:  -EarthMu / (2 * a),
+		AngularMomentum: math.Sqrt(EarthMu * a * (1 - e*e)),
+	}
+}
+
+// OsculatingElements returns the osculating Keplerian elements of the propagated
+// state at targetTime
+func (p *Propagator) OsculatingElements(targetTime time.Time) KeplerianElements {
+	return StateToKeplerian(p.StateECI(targetTime))
+}
+
+// trueAnomaly solves Kepler's equation for the true anomaly [Rad] from the mean anomaly [Rad]
+func trueAnomaly(e, meanAnomaly float64) float64 {
+	eccentric := kepler.SolveKepler(e, math.Remainder(meanAnomaly, 2*math.Pi))
+	sinE, cosE := math.Sincos(eccentric)
+	return math.Atan2(math.Sqrt(1-e*e)*sinE, cosE-e)
+}
+
+// degrees converts an angle [Rad] to degrees in [0, 360)
+func degrees(rad float64) float64 {
+	deg := math.Mod(util.Rad2Deg(rad), 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+// clamp limits a cosine to [-1, 1] against rounding
+func clamp(x float64) float64 {
+	return math.Max(-1, math.Min(1, x))
+}
@@ -0,0 +1,60 @@
+package orbital
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// EarthRotationRate is the rotation rate of the Earth relative to the equatorial frame [Rad/s]
+const EarthRotationRate = 1.002737909 * 2 * math.Pi / 86400.0
+
+// EarthFixedState converts a state vector from the equatorial frame to the
+// Earth-fixed frame, removing the Earth's rotation from the velocity
+func EarthFixedState(state *model.StateVector) *model.StateVector {
+	position := vecmath.Vec3{X: state.X, Y: state.Y, Z: state.Z}
+	velocity := vecmath.Vec3{X: state.VX, Y: state.VY, Z: state.VZ}
+	position, velocity = toEarthFixed(position, velocity, state.Time)
+
+	fixed := stateVector(state.Time, position, velocity)
+	return &fixed
+}
+
+// EarthFixedToGeodetic converts Earth-fixed coordinates [km] to latitude [Degree],
+// longitude [Degree] and altitude [km], using the same spherical Earth as the propagator
+func EarthFixedToGeodetic(x, y, z float64) (float64, float64, float64) {
+	lat, lng := calculateLatLong(x, y, z)
+	return lat, lng, calculateAltitude(x, y, z)
+}
+
+// GeodeticToEarthFixed converts WGS-84 geodetic latitude [Degree], longitude [Degree]
+// and height above the ellipsoid [km] to Earth-fixed coordinates [km]
+func GeodeticToEarthFixed(lat, lng, alt float64) vecmath.Vec3 {
+	sinLat, cosLat := math.Sincos(util.Deg2Rad(lat))
+	sinLng, cosLng := math.Sincos(util.Deg2Rad(lng))
+
+	// Radius of curvature in the prime vertical
+	e2 := util.WGS84Flattening * (2 - util.WGS84Flattening)
+	n := util.WGS84EquatorialRadius / math.Sqrt(1-e2*sinLat*sinLat)
+
+	return vecmath.Vec3{
+		X: (n + alt) * cosLat * cosLng,
+		Y: (n + alt) * cosLat * sinLng,
+		Z: (n*(1-e2) + alt) * sinLat,
+	}
+}
+
+// EquatorialToEarthFixed rotates a position from the equatorial frame into the
+// Earth-fixed frame at targetTime
+func EquatorialToEarthFixed(position vecmath.Vec3, targetTime time.Time) vecmath.Vec3 {
+	return vecmath.RotZ(-greenwichSiderealAngle(targetTime)).MulVec(position)
+}
+
+// EarthFixedToEquatorial rotates a vector from the Earth-fixed frame into the
+// equatorial frame at targetTime
+func EarthFixedToEquatorial(vector vecmath.Vec3, targetTime time.Time) vecmath.Vec3 {
+	return vecmath.RotZ(greenwichSiderealAngle(targetTime)).MulVec(vector)
+}
@@ -0,0 +1,127 @@
+package orbital
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/util"
+)
+
+// Thresholds used by CheckElementHealth
+const (
+	// MaxEpochAgeDays is the epoch age beyond which an element set is considered stale [Day]
+	MaxEpochAgeDays = 7.0
+	// MaxMeanMotionDot is the |ndot| beyond which the orbit is considered to be decaying or manoeuvring [Rev/Day2]
+	MaxMeanMotionDot = 0.001
+	// MaxBStar is the |B*| beyond which the drag term is considered abnormal [1/EarthRadii]
+	MaxBStar = 0.01
+	// MinPerigeeAltitude is the perigee altitude below which the orbit is close to re-entry [km]
+	MinPerigeeAltitude = 150.0
+)
+
+// EpochTime returns the epoch of the element set as a UTC time
+func EpochTime(sat *model.TleOrbitalElement) time.Time {
+	return epochTime(sat.EtYear, sat.EtDay)
+}
+
+// epochTime converts a TLE epoch year and fractional day of year to a UTC time
+func epochTime(year int, day float64) time.Time {
+	// The day of year is 1-based: day 1.0 is January 1st 00:00 UTC
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return yearStart.Add(time.Duration((day - 1.0) * 86400.0 * float64(time.Second)))
+}
+
+// EpochFields converts a time to the TLE epoch year and fractional day of year,
+// the inverse of EpochTime
+func EpochFields(t time.Time) (int, float64) {
+	t = t.UTC()
+	yearStart := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	return t.Year(), 1.0 + t.Sub(yearStart).Seconds()/86400.0
+}
+
+// SemiMajorAxis returns the semi-major axis implied by the mean motion [km]
+func SemiMajorAxis(sat *model.TleOrbitalElement) float64 {
+	a, _ := calculateOrbitalSemiAxes(sat.MeanMotion)
+	return a
+}
+
+// MeanMotionRate returns the rate of change of the mean motion dn/dt [Rev/Day2] of an
+// element set. This is the one definition of how MeanMotionDot is read: the TLE format
+// defines that field as ndot/2, but the propagator has always taken it as dn/dt itself
+// (M = M0 + n t + 1/2 dn/dt t^2), and decay prediction, maneuver detection and TLE
+// fitting follow the propagator so that their drag rates agree with its predictions.
+// Rates derived from TLEs of other sources are therefore half the physical ones.
+func MeanMotionRate(sat *model.TleOrbitalElement) float64 {
+	return sat.MeanMotionDot
+}
+
+// SetMeanMotionRate stores a rate of change of the mean motion dn/dt [Rev/Day2] in an
+// element set, the inverse of MeanMotionRate
+func SetMeanMotionRate(sat *model.TleOrbitalElement, rate float64) {
+	sat.MeanMotionDot = rate
+}
+
+// PerigeeAltitude returns the perigee altitude implied by the mean motion and eccentricity [km]
+func PerigeeAltitude(sat *model.TleOrbitalElement) float64 {
+	a, _ := calculateOrbitalSemiAxes(sat.MeanMotion)
+	return a*(1-sat.Eccentricity) - util.EarthRadius
+}
+
+// CheckElementHealth inspects an element set for conditions that make positions
+// propagated to targetTime unreliable, returning one warning per problem found
+func CheckElementHealth(sat *model.TleOrbitalElement, targetTime time.Time) []string {
+	var warnings []string
+
+	// Epoch age relative to the propagation time (in either direction)
+	ageDays := targetTime.Sub(EpochTime(sat)).Hours() / 24.0
+	if math.Abs(ageDays) > MaxEpochAgeDays {
+		warnings = append(warnings, fmt.Sprintf(
+			"stale element set: epoch is %.1f days from propagation time (limit %.0f)", ageDays, MaxEpochAgeDays))
+	}
+
+	// Mean motion derivative: large values indicate decay or manoeuvres
+	if math.Abs(sat.MeanMotionDot) > MaxMeanMotionDot {
+		warnings = append(warnings, fmt.Sprintf(
+			"abnormal mean motion derivative: %.8f rev/day2 (limit %.3f)", sat.MeanMotionDot, MaxMeanMotionDot))
+	}
+
+	// B* drag term
+	if math.Abs(sat.BStar) > MaxBStar {
+		warnings = append(warnings, fmt.Sprintf(
+			"abnormal B* drag term: %.5e 1/ER (limit %.2f)", sat.BStar, MaxBStar))
+	}
+
+	// Eccentricity must describe a closed orbit
+	if sat.Eccentricity < 0 || sat.Eccentricity >= 1 {
+		warnings = append(warnings, fmt.Sprintf(
+			"eccentricity out of range: %.7f (expected 0 <= e < 1)", sat.Eccentricity))
+	}
+
+	// Mean motion must be positive; otherwise the perigee cannot be derived
+	if sat.MeanMotion <= 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"mean motion out of range: %.8f rev/day", sat.MeanMotion))
+	} else if perigee := PerigeeAltitude(sat); perigee < MinPerigeeAltitude {
+		warnings = append(warnings, fmt.Sprintf(
+			"perigee altitude %.1f km is below %.0f km", perigee, MinPerigeeAltitude))
+	}
+
+	return warnings
+}
+
+// ValidateElements returns an error when an element set cannot be propagated at all
+func ValidateElements(sat *model.TleOrbitalElement) error {
+	if sat == nil {
+		return errors.New("missing orbital elements")
+	}
+	if sat.MeanMotion <= 0 {
+		return fmt.Errorf("mean motion out of range: %.8f rev/day", sat.MeanMotion)
+	}
+	if sat.Eccentricity < 0 || sat.Eccentricity >= 1 {
+		return fmt.Errorf("eccentricity out of range: %.7f", sat.Eccentricity)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package orbital
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/kepler"
+	"starlink/pkg/model"
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// Propagator propagates a single element set. Everything that does not depend on
+// the target time (semi-major axis, secular rates, inclination rotation) is computed
+// once by NewPropagator, and the propagation methods do not allocate.
+type Propagator struct {
+	elements model.TleOrbitalElement
+	epoch    time.Time
+
+	m0  float64 // Mean anomaly at epoch [Degree]
+	m1  float64 // Mean motion [Rev/Day]
+	m2  float64 // Rate of change of the mean motion, see MeanMotionRate [Rev/Day2]
+	ecc float64 // Eccentricity [-]
+	a   float64 // Semi-major axis [km]
+
+	angleOmegaA0_Rad   float64      // Argument of perigee at epoch [Rad]
+	angleOmegaB0_Rad   float64      // RAAN at epoch [Rad]
+	angleOmegaADot_Rad float64      // Argument of perigee drift [Rad/Day]
+	angleOmegaBDot_Rad float64      // RAAN drift [Rad/Day]
+	inclination        vecmath.Mat3 // Rotation about the x axis by the inclination
+}
+
+// NewPropagator validates an element set and prepares it for repeated propagation
+func NewPropagator(sat *model.TleOrbitalElement) (*Propagator, error) {
+	if err := ValidateElements(sat); err != nil {
+		return nil, err
+	}
+	return newPropagator(sat), nil
+}
+
+// newPropagator prepares an element set without validating it
+func newPropagator(sat *model.TleOrbitalElement) *Propagator {
+	a, _ := calculateOrbitalSemiAxes(sat.MeanMotion)
+	angleOmegaADot, angleOmegaBDot := calculateSecularRates(sat.OrbitalInclination, a)
+
+	return &Propagator{
+		elements:           *sat,
+		epoch:              EpochTime(sat),
+		m0:                 sat.MeanAnomaly,
+		m1:                 sat.MeanMotion,
+		m2:                 MeanMotionRate(sat),
+		ecc:                sat.Eccentricity,
+		a:                  a,
+		angleOmegaA0_Rad:   util.Deg2Rad(sat.ArgumentOfPerigee),
+		angleOmegaB0_Rad:   util.Deg2Rad(sat.Raan),
+		angleOmegaADot_Rad: util.Deg2Rad(angleOmegaADot),
+		angleOmegaBDot_Rad: util.Deg2Rad(angleOmegaBDot),
+		inclination:        vecmath.RotX(util.Deg2Rad(sat.OrbitalInclination)),
+	}
+}
+
+// Elements returns the element set being propagated
+func (p *Propagator) Elements() *model.TleOrbitalElement {
+	return &p.elements
+}
+
+// Epoch returns the epoch of the element set
+func (p *Propagator) Epoch() time.Time {
+	return p.epoch
+}
+
+// SemiMajorAxis returns the semi-major axis derived from the mean motion [km]
+func (p *Propagator) SemiMajorAxis() float64 {
+	return p.a
+}
+
+// MeanElementsAt returns the element set advanced to targetTime by the secular model
+// of the propagator: the mean anomaly and mean motion follow the mean motion
+// derivative, and the RAAN and argument of perigee drift at the J2 rates
+func (p *Propagator) MeanElementsAt(targetTime time.Time) *model.TleOrbitalElement {
+	t_diff := calculateTimeDifference(targetTime, p.epoch)
+	el := p.elements
+	el.EtYear, el.EtDay = EpochFields(targetTime)
+	el.MeanAnomaly = degrees(util.Deg2Rad(p.m0) + calculateMeanAnomaly(0, p.m1, p.m2, t_diff))
+	el.MeanMotion = p.m1 + p.m2*t_diff
+	el.Raan = degrees(p.angleOmegaB0_Rad + p.angleOmegaBDot_Rad*t_diff)
+	el.ArgumentOfPerigee = degrees(p.angleOmegaA0_Rad + p.angleOmegaADot_Rad*t_diff)
+	return &el
+}
+
+// StateECI returns position and velocity in the equatorial (Earth-centred inertial) frame
+func (p *Propagator) StateECI(targetTime time.Time) model.StateVector {
+	position, velocity := p.equatorialState(targetTime)
+	return stateVector(targetTime, position, velocity)
+}
+
+// PositionECI returns the position in the equatorial frame [km]
+func (p *Propagator) PositionECI(targetTime time.Time) vecmath.Vec3 {
+	position, _ := p.equatorialState(targetTime)
+	return position
+}
+
+// StateECEF returns position and velocity in the Earth-fixed frame
+func (p *Propagator) StateECEF(targetTime time.Time) model.StateVector {
+	position, velocity := p.equatorialState(targetTime)
+	position, velocity = toEarthFixed(position, velocity, targetTime)
+	return stateVector(targetTime, position, velocity)
+}
+
+// Location returns the Earth-fixed position, sub-satellite point, altitude and
+// Earth-fixed speed. Element set health warnings are not included.
+func (p *Propagator) Location(targetTime time.Time) model.SatLocation {
+	position, velocity := p.equatorialState(targetTime)
+	position, velocity = toEarthFixed(position, velocity, targetTime)
+	lat, lng := calculateLatLong(position.X, position.Y, position.Z)
+
+	return model.SatLocation{
+		X:        position.X,
+		Y:        position.Y,
+		Z:        position.Z,
+		Lat:      lat,
+		Lng:      lng,
+		Alt:      calculateAltitude(position.X, position.Y, position.Z),
+		Velocity: velocity.Norm(),
+	}
+}
+
+// equatorialState computes position [km] and velocity [km/s] in the equatorial frame
+func (p *Propagator) equatorialState(targetTime time.Time) (vecmath.Vec3, vecmath.Vec3) {
+	// Time since epoch [Day]
+	t_diff := calculateTimeDifference(targetTime, p.epoch)
+
+	// Mean anomaly and Kepler's equation
+	fracM_Radian := calculateMeanAnomaly(p.m0, p.m1, p.m2, t_diff)
+	eccentricAnomaly := kepler.SolveKepler(p.ecc, fracM_Radian)
+
+	// Position and velocity in the orbital plane
+	u, v := calculatePositionInOrbitalPlane(p.a, p.ecc, eccentricAnomaly)
+	uDot, vDot := calculateVelocityInOrbitalPlane(p.a, p.ecc, eccentricAnomaly, p.m1, p.m2, t_diff)
+
+	// Apply perturbation corrections
+	angleOmegaA_Rad := p.angleOmegaA0_Rad + p.angleOmegaADot_Rad*t_diff
+	angleOmegaB_Rad := p.angleOmegaB0_Rad + p.angleOmegaBDot_Rad*t_diff
+	angleOmegaADot_RadSec := p.angleOmegaADot_Rad / 86400.0
+	angleOmegaBDot_RadSec := p.angleOmegaBDot_Rad / 86400.0
+
+	// Transform from orbital plane to equatorial reference frame
+	rotation := vecmath.RotZ(angleOmegaB_Rad).Mul(p.inclination).Mul(vecmath.RotZ(angleOmegaA_Rad))
+	position := rotation.MulVec(vecmath.Vec3{X: u, Y: v})
+
+	// The velocity includes the rotation of the perigee within the plane and
+	// the regression of the ascending node about the z axis
+	velocity := rotation.MulVec(vecmath.Vec3{
+		X: uDot - angleOmegaADot_RadSec*v,
+		Y: vDot + angleOmegaADot_RadSec*u,
+	})
+	velocity.X -= angleOmegaBDot_RadSec * position.Y
+	velocity.Y += angleOmegaBDot_RadSec * position.X
+
+	if util.IsDebugEnabled() {
+		util.LogDebug("targetTime=%v\n", targetTime)
+		util.LogDebug("t_diff=%v\n", t_diff)
+		util.LogDebug("a [km] =%v\n", p.a)
+		util.LogDebug("fracM (Radian) =%v\n", fracM_Radian)
+		util.LogDebug("eccentricAnomaly=%v\n", eccentricAnomaly)
+		util.LogDebug("u (km)=%v v (km)=%v\n", u, v)
+		util.LogDebug("angleOmegaA (Degree)=%v\n", util.Rad2Deg(angleOmegaA_Rad))
+		util.LogDebug("angleOmegaB (Degree)=%v\n", util.Rad2Deg(angleOmegaB_Rad))
+		util.LogDebug("x (km) =%v y (km) =%v z (km) =%v\n", position.X, position.Y, position.Z)
+		util.LogDebug("vx (km/s) =%v vy (km/s) =%v vz (km/s) =%v\n", velocity.X, velocity.Y, velocity.Z)
+	}
+
+	return position, velocity
+}
+
+// toEarthFixed rotates an equatorial position and velocity into the Earth-fixed frame
+func toEarthFixed(position, velocity vecmath.Vec3, targetTime time.Time) (vecmath.Vec3, vecmath.Vec3) {
+	rotation := vecmath.RotZ(-greenwichSiderealAngle(targetTime))
+	position = rotation.MulVec(position)
+
+	// v_fixed = R * v_inertial - omega x r_fixed
+	velocity = rotation.MulVec(velocity)
+	velocity.X += EarthRotationRate * position.Y
+	velocity.Y -= EarthRotationRate * position.X
+
+	return position, velocity
+}
+
+// stateVector packs position and velocity vectors into a model.StateVector
+func stateVector(t time.Time, position, velocity vecmath.Vec3) model.StateVector {
+	return model.StateVector{
+		Time: t.UTC(),
+		X:    position.X,
+		Y:    position.Y,
+		Z:    position.Z,
+		VX:   velocity.X,
+		VY:   velocity.Y,
+		VZ:   velocity.Z,
+	}
+}
+
+// siderealReference is the reference time of the sidereal angle (2006-01-01T00:00:00Z)
+var siderealReference = time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// greenwichSiderealAngle returns the rotation angle of the Earth-fixed frame
+// relative to the equatorial frame at targetTime [Rad]
+func greenwichSiderealAngle(targetTime time.Time) float64 {
+	sheta0 := 0.27644444444
+	t_diff1 := targetTime.Sub(siderealReference).Seconds() / 86400.0
+	sheta := sheta0 + 1.002737909*t_diff1
+
+	// Convert fractional part to angle
+	return (sheta - math.Floor(sheta)) * 2.0 * math.Pi
+}
@@ -0,0 +1,104 @@
+package orbital_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/orbital"
+	"starlink/pkg/tle"
+)
+
+// STARLINK-1008 from tle.txt
+const (
+	testLine1 = "1 44714U 19074B   25117.42924319 -.00001157  00000+0 -58773-4 0  9990"
+	testLine2 = "2 44714  53.0517 166.3609 0001116  99.1558 260.9557 15.06400606301084"
+)
+
+func testPropagator(tb testing.TB) (*model.TleOrbitalElement, *orbital.Propagator, time.Time) {
+	tb.Helper()
+	sat := tle.ParseTleFromStrings(testLine1, testLine2)
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return sat, p, p.Epoch().Add(90 * time.Minute)
+}
+
+func TestPropagatorDoesNotAllocate(t *testing.T) {
+	_, p, target := testPropagator(t)
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Location(target)
+		p.StateECI(target)
+		p.StateECEF(target)
+	})
+	if allocs != 0 {
+		t.Errorf("propagation allocates %.0f times per run, want 0", allocs)
+	}
+}
+
+// TestPropagatorLocationGolden checks the propagator against the location of
+// STARLINK-1154 from tle.txt computed by the gonum implementation it replaced. That
+// implementation truncated the epoch to whole seconds, so the object is one whose epoch
+// lies 32 microseconds after a whole second.
+func TestPropagatorLocationGolden(t *testing.T) {
+	sat := tle.ParseTleFromStrings(
+		"1 45198U 20012W   25117.71306713  .00005555  00000+0  39139-3 0  9993",
+		"2 45198  53.0557   5.0904 0001181  85.7617 274.3507 15.06413570287431")
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.Location(time.Date(2025, time.April, 27, 18, 0, 0, 0, time.UTC))
+	want := model.SatLocation{
+		X:        1985.6873745272298,
+		Y:        6344.7109588311187,
+		Z:        -1940.6947999881488,
+		Lat:      -16.273245489278157,
+		Lng:      72.621583746027142,
+		Alt:      568.89654776573025,
+		Velocity: 7.2932039777223521,
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+		tolerance float64
+	}{
+		{"X", got.X, want.X, 1e-3},
+		{"Y", got.Y, want.Y, 1e-3},
+		{"Z", got.Z, want.Z, 1e-3},
+		{"Lat", got.Lat, want.Lat, 1e-5},
+		{"Lng", got.Lng, want.Lng, 1e-5},
+		{"Alt", got.Alt, want.Alt, 1e-6},
+		{"Velocity", got.Velocity, want.Velocity, 1e-6},
+	} {
+		if math.Abs(c.got-c.want) > c.tolerance {
+			t.Errorf("%s = %.9f, want %.9f", c.name, c.got, c.want)
+		}
+	}
+}
+
+func BenchmarkPropagatorLocation(b *testing.B) {
+	_, p, target := testPropagator(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		p.Location(target)
+	}
+}
+
+func BenchmarkPropagatorStateECI(b *testing.B) {
+	_, p, target := testPropagator(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		p.StateECI(target)
+	}
+}
+
+func BenchmarkCalculateSatelliteLocation(b *testing.B) {
+	sat, _, target := testPropagator(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		orbital.CalculateSatelliteLocation(sat, target)
+	}
+}
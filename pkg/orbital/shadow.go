@@ -0,0 +1,162 @@
+package orbital
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// SunRadius is the radius of the Sun [km]
+const SunRadius = 696000.0
+
+// ShadowModel selects the geometry of Earth's shadow
+type ShadowModel int
+
+const (
+	// ShadowConical models umbra and penumbra cones from the finite size of the Sun
+	ShadowConical ShadowModel = iota
+	// ShadowCylindrical models the shadow as a cylinder with no penumbra
+	ShadowCylindrical
+)
+
+// String returns the name of the shadow model as accepted by ParseShadowModel
+func (m ShadowModel) String() string {
+	if m == ShadowCylindrical {
+		return "cylindrical"
+	}
+	return "conical"
+}
+
+// ParseShadowModel parses a shadow model name (conical or cylindrical)
+func ParseShadowModel(name string) (ShadowModel, error) {
+	switch strings.ToLower(name) {
+	case "conical":
+		return ShadowConical, nil
+	case "cylindrical":
+		return ShadowCylindrical, nil
+	default:
+		return 0, fmt.Errorf("unknown shadow model %q (expected conical or cylindrical)", name)
+	}
+}
+
+// ShadowState is the eclipse state of a satellite
+type ShadowState int
+
+const (
+	// Sunlit means the whole solar disc is visible
+	Sunlit ShadowState = iota
+	// Penumbra means the solar disc is partly hidden by the Earth
+	Penumbra
+	// Umbra means the solar disc is completely hidden by the Earth
+	Umbra
+)
+
+// String returns the name of the shadow state
+func (s ShadowState) String() string {
+	switch s {
+	case Penumbra:
+		return "penumbra"
+	case Umbra:
+		return "umbra"
+	default:
+		return "sunlit"
+	}
+}
+
+// Illumination describes how much of the Sun a satellite sees
+type Illumination struct {
+	State    ShadowState // Eclipse state
+	Fraction float64     // Visible fraction of the solar disc, 1 when sunlit and 0 in umbra
+}
+
+// CalculateIllumination evaluates Earth's shadow at a satellite position given the
+// Sun position, both in the equatorial frame [km]
+func CalculateIllumination(position, sun vecmath.Vec3, shadowModel ShadowModel) Illumination {
+	if shadowModel == ShadowCylindrical {
+		return cylindricalIllumination(position, sun)
+	}
+	return conicalIllumination(position, sun)
+}
+
+// cylindricalIllumination treats the shadow as a cylinder of Earth's radius behind the Earth
+func cylindricalIllumination(position, sun vecmath.Vec3) Illumination {
+	sunDir := sun.Unit()
+	along := position.Dot(sunDir)
+	if along >= 0 {
+		return Illumination{State: Sunlit, Fraction: 1}
+	}
+
+	perpendicular := position.Sub(sunDir.Scale(along)).Norm()
+	if perpendicular < util.WGS84EquatorialRadius {
+		return Illumination{State: Umbra, Fraction: 0}
+	}
+	return Illumination{State: Sunlit, Fraction: 1}
+}
+
+// conicalIllumination compares the apparent discs of the Sun and the Earth as seen
+// from the satellite and computes the visible fraction of the solar disc from their overlap
+func conicalIllumination(position, sun vecmath.Vec3) Illumination {
+	a, b, c := shadowDiscs(position, sun)
+
+	switch {
+	case c >= a+b:
+		// Discs do not overlap
+		return Illumination{State: Sunlit, Fraction: 1}
+	case c <= b-a:
+		// Sun disc entirely behind the Earth
+		return Illumination{State: Umbra, Fraction: 0}
+	case c <= a-b:
+		// Earth disc entirely inside the Sun disc (annular)
+		return Illumination{State: Penumbra, Fraction: 1 - (b*b)/(a*a)}
+	}
+
+	// Partial overlap of the two discs
+	x := (c*c + a*a - b*b) / (2 * c)
+	y := math.Sqrt(math.Max(a*a-x*x, 0))
+	area := a*a*math.Acos(x/a) + b*b*math.Acos((c-x)/b) - c*y
+
+	return Illumination{State: Penumbra, Fraction: 1 - area/(math.Pi*a*a)}
+}
+
+// shadowDiscs returns the apparent radius of the Sun (a), the apparent radius of the
+// Earth (b) and their angular separation (c) as seen from the satellite [Rad]
+func shadowDiscs(position, sun vecmath.Vec3) (float64, float64, float64) {
+	toSun := sun.Sub(position)
+	toEarth := position.Scale(-1)
+
+	a := math.Asin(math.Min(SunRadius/toSun.Norm(), 1))
+	b := math.Asin(math.Min(util.WGS84EquatorialRadius/toEarth.Norm(), 1))
+	cosC := toSun.Dot(toEarth) / (toSun.Norm() * toEarth.Norm())
+	c := math.Acos(math.Max(-1, math.Min(1, cosC)))
+
+	return a, b, c
+}
+
+// ShadowBoundaries returns the signed distances to the penumbra and umbra boundaries,
+// each negative inside the corresponding region: the distance from the shadow cylinder
+// [km] for the cylindrical model, where both boundaries coincide and the day side is 1,
+// and the angular distance between the limbs of the Sun and Earth discs [Rad] for the
+// conical model.
+func ShadowBoundaries(position, sun vecmath.Vec3, shadowModel ShadowModel) (float64, float64) {
+	if shadowModel == ShadowCylindrical {
+		sunDir := sun.Unit()
+		along := position.Dot(sunDir)
+		if along >= 0 {
+			return 1, 1
+		}
+		d := position.Sub(sunDir.Scale(along)).Norm() - util.WGS84EquatorialRadius
+		return d, d
+	}
+
+	a, b, c := shadowDiscs(position, sun)
+	return c - (a + b), c - (b - a)
+}
+
+// Illumination evaluates Earth's shadow at the satellite's position at targetTime
+func (p *Propagator) Illumination(targetTime time.Time, shadowModel ShadowModel) Illumination {
+	return CalculateIllumination(p.PositionECI(targetTime), SunPosition(targetTime), shadowModel)
+}
@@ -0,0 +1,91 @@
+package orbital
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/util"
+	"starlink/pkg/vecmath"
+)
+
+// AstronomicalUnit is the mean Earth-Sun distance [km]
+const AstronomicalUnit = 149597870.7
+
+// J2000 is the Julian date of the J2000.0 epoch
+const J2000 = 2451545.0
+
+// SunPosition returns the geocentric position of the Sun in the equatorial frame
+// used by the propagator [km]. It uses the low-precision formulae of the
+// Astronomical Almanac, accurate to about 0.01 degree between 1950 and 2050.
+func SunPosition(targetTime time.Time) vecmath.Vec3 {
+	n := util.JulianDate(targetTime) - J2000
+
+	// Mean longitude and mean anomaly [Degree]
+	meanLongitude := 280.460 + 0.9856474*n
+	g := util.Deg2Rad(357.528 + 0.9856003*n)
+
+	// Ecliptic longitude, latitude is taken as zero
+	lambda := util.Deg2Rad(meanLongitude + 1.915*math.Sin(g) + 0.020*math.Sin(2*g))
+
+	// Distance [AU]
+	r := 1.00014 - 0.01671*math.Cos(g) - 0.00014*math.Cos(2*g)
+
+	return eclipticToEquatorial(lambda, 0, r*AstronomicalUnit, n)
+}
+
+// MoonPosition returns the geocentric position of the Moon in the equatorial frame
+// used by the propagator [km]. It uses the low-precision formulae of the
+// Astronomical Almanac, accurate to about 0.3 degree in direction and 0.2 % in distance.
+func MoonPosition(targetTime time.Time) vecmath.Vec3 {
+	n := util.JulianDate(targetTime) - J2000
+	T := n / 36525.0
+
+	sinDeg := func(deg float64) float64 { return math.Sin(util.Deg2Rad(deg)) }
+	cosDeg := func(deg float64) float64 { return math.Cos(util.Deg2Rad(deg)) }
+
+	// Ecliptic longitude [Degree]
+	lambda := 218.32 + 481267.881*T +
+		6.29*sinDeg(135.0+477198.87*T) - 1.27*sinDeg(259.3-413335.36*T) +
+		0.66*sinDeg(235.7+890534.22*T) + 0.21*sinDeg(269.9+954397.74*T) -
+		0.19*sinDeg(357.5+35999.05*T) - 0.11*sinDeg(186.5+966404.03*T)
+
+	// Ecliptic latitude [Degree]
+	beta := 5.13*sinDeg(93.3+483202.02*T) + 0.28*sinDeg(228.2+960400.89*T) -
+		0.28*sinDeg(318.3+6003.15*T) - 0.17*sinDeg(217.6-407332.21*T)
+
+	// Horizontal parallax [Degree], which gives the distance
+	parallax := 0.9508 + 0.0518*cosDeg(134.9+477198.85*T) +
+		0.0095*cosDeg(259.2-413335.38*T) + 0.0078*cosDeg(235.7+890534.23*T) +
+		0.0028*cosDeg(269.9+954397.70*T)
+	distance := util.WGS84EquatorialRadius / sinDeg(parallax)
+
+	return eclipticToEquatorial(util.Deg2Rad(lambda), util.Deg2Rad(beta), distance, n)
+}
+
+// RightAscensionDeclination returns the right ascension [Degree, 0-360), declination
+// [Degree] and distance [km] of an equatorial position vector
+func RightAscensionDeclination(position vecmath.Vec3) (float64, float64, float64) {
+	distance := position.Norm()
+	ra := util.Rad2Deg(math.Atan2(position.Y, position.X))
+	if ra < 0 {
+		ra += 360.0
+	}
+	dec := util.Rad2Deg(math.Asin(position.Z / distance))
+	return ra, dec, distance
+}
+
+// eclipticToEquatorial converts ecliptic longitude and latitude [Rad] and distance
+// to an equatorial position, using the obliquity of the ecliptic n days after J2000
+func eclipticToEquatorial(lambda, beta, distance, n float64) vecmath.Vec3 {
+	epsilon := util.Deg2Rad(23.439 - 0.0000004*n)
+
+	sinLambda, cosLambda := math.Sincos(lambda)
+	sinBeta, cosBeta := math.Sincos(beta)
+	sinEps, cosEps := math.Sincos(epsilon)
+
+	return vecmath.Vec3{
+		X: distance * cosBeta * cosLambda,
+		Y: distance * (cosEps*cosBeta*sinLambda - sinEps*sinBeta),
+		Z: distance * (sinEps*cosBeta*sinLambda + cosEps*sinBeta),
+	}
+}
@@ -0,0 +1,47 @@
+package orbital_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"starlink/pkg/orbital"
+)
+
+// angleDiff returns the difference of two angles in [-180, 180) [degree]
+func angleDiff(a, b float64) float64 {
+	return math.Mod(a-b+540.0, 360.0) - 180.0
+}
+
+// Meeus, Astronomical Algorithms, example 25.a: apparent Sun on 1992-10-13 0h TD,
+// taken as UTC (TD - UTC was about a minute, well within the tolerances)
+func TestSunPositionMeeus25a(t *testing.T) {
+	ra, dec, distance := orbital.RightAscensionDeclination(
+		orbital.SunPosition(time.Date(1992, 10, 13, 0, 0, 0, 0, time.UTC)))
+
+	if d := angleDiff(ra, 198.38083); math.Abs(d) > 0.01 {
+		t.Errorf("right ascension %.5f°, off by %.5f°", ra, d)
+	}
+	if d := dec - -7.78507; math.Abs(d) > 0.01 {
+		t.Errorf("declination %.5f°, off by %.5f°", dec, d)
+	}
+	if au := distance / orbital.AstronomicalUnit; math.Abs(au-0.99760775) > 1e-4 {
+		t.Errorf("distance %.8f AU, want 0.99760775", au)
+	}
+}
+
+// Meeus, Astronomical Algorithms, example 47.a: apparent Moon on 1992-04-12 0h TD
+func TestMoonPositionMeeus47a(t *testing.T) {
+	ra, dec, distance := orbital.RightAscensionDeclination(
+		orbital.MoonPosition(time.Date(1992, 4, 12, 0, 0, 0, 0, time.UTC)))
+
+	if d := angleDiff(ra, 134.688470); math.Abs(d) > 0.3 {
+		t.Errorf("right ascension %.4f°, off by %.4f°", ra, d)
+	}
+	if d := dec - 13.768368; math.Abs(d) > 0.3 {
+		t.Errorf("declination %.4f°, off by %.4f°", dec, d)
+	}
+	if rel := distance/368409.7 - 1; math.Abs(rel) > 0.002 {
+		t.Errorf("distance %.1f km, off by %.3f %%", distance, rel*100)
+	}
+}
@@ -0,0 +1,81 @@
+package passes
+
+import (
+	"math"
+	"time"
+
+	"starlink/pkg/brightness"
+	"starlink/pkg/observer"
+)
+
+// Segment is a portion of a pass in which the satellite is optically visible
+type Segment struct {
+	Start Event // Satellite becomes visible (rises, leaves the umbra or the sky darkens)
+	Stop  Event // Satellite stops being visible
+
+	Brightest Event // Time of the brightest apparent magnitude within the segment
+}
+
+// Duration returns the length of the segment
+func (s Segment) Duration() time.Duration {
+	return s.Stop.Time.Sub(s.Start.Time)
+}
+
+// classify finds the optically visible segments of a pass and sets its visibility.
+// Entering or leaving the Earth's shadow and twilight changes are slow compared with
+// the coarse step, so each boundary is bracketed by a single sign change.
+func (f *finder) classify(pass *Pass) {
+	margin := f.station.OpticalMargin(f.propagator, f.opts.Twilight)
+	rise, set := pass.AOS.Time, pass.LOS.Time
+
+	var start time.Time
+	t0, m0 := rise, margin(rise)
+	if m0 > 0 {
+		start = t0
+	}
+
+	for t0.Before(set) {
+		t1 := t0.Add(f.opts.Step)
+		if t1.After(set) {
+			t1 = set
+		}
+		m1 := margin(t1)
+
+		switch {
+		case m0 <= 0 && m1 > 0:
+			start = f.crossing(t0, t1, margin)
+		case m0 > 0 && m1 <= 0:
+			pass.Visible = append(pass.Visible, f.newSegment(start, f.crossing(t0, t1, margin)))
+		}
+
+		t0, m0 = t1, m1
+	}
+	if m0 > 0 {
+		pass.Visible = append(pass.Visible, f.newSegment(start, set))
+	}
+
+	if len(pass.Visible) > 0 {
+		pass.Visibility = observer.Visible
+	} else {
+		pass.Visibility = f.station.Visibility(f.propagator, pass.TCA.Time, f.opts.Twilight)
+	}
+}
+
+// newSegment builds a visible segment between start and stop, locating the time of
+// the brightest magnitude
+func (f *finder) newSegment(start, stop time.Time) Segment {
+	// Negated magnitude, so that the maximum is the brightest
+	brighter := func(t time.Time) float64 {
+		magnitude, lit := brightness.Estimate(f.propagator, f.station, t, f.opts.StandardMagnitude)
+		if !lit {
+			return math.Inf(-1)
+		}
+		return -magnitude
+	}
+
+	return Segment{
+		Start:     f.event(start),
+		Stop:      f.event(stop),
+		Brightest: f.event(f.maximum(start, stop, brighter)),
+	}
+}
@@ -0,0 +1,223 @@
+package passes
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"starlink/pkg/brightness"
+	"starlink/pkg/model"
+	"starlink/pkg/observer"
+	"starlink/pkg/orbital"
+)
+
+// DefaultStep is the coarse sampling interval used to bracket horizon crossings.
+// It is short compared with a LEO pass, and elevation peaks between samples are
+// refined separately so that brief low passes are not missed.
+const DefaultStep = 30 * time.Second
+
+// Tolerance is the precision to which event times are refined
+const Tolerance = time.Millisecond
+
+// Event is a pass event as seen from the observer
+type Event struct {
+	Time      time.Time // Event time (UTC)
+	Azimuth   float64   // Azimuth [degree]
+	Elevation float64   // Elevation [degree]
+	Magnitude float64   // Apparent visual magnitude, only with Options.Optical; NaN when the satellite is in umbra
+}
+
+// Pass is a single visibility pass of a satellite over an observer
+type Pass struct {
+	Satellite      string // Satellite name
+	AOS            Event  // Acquisition of signal (rise above the mask)
+	TCA            Event  // Culmination: time of maximum elevation
+	LOS            Event  // Loss of signal (set below the mask)
+	StartTruncated bool   // The pass was already in progress at the window start; AOS is the window start
+	EndTruncated   bool   // The pass was still in progress at the window stop; LOS is the window stop
+
+	// Optical visibility, only evaluated when Options.Optical is set
+	Visibility observer.Visibility // Visible if any segment is visible, otherwise daylight or eclipsed at culmination
+	Visible    []Segment           // Portions of the pass in which the satellite is optically visible
+}
+
+// Duration returns the time between AOS and LOS
+func (p Pass) Duration() time.Duration {
+	return p.LOS.Time.Sub(p.AOS.Time)
+}
+
+// MaxElevation returns the elevation at culmination [degree]
+func (p Pass) MaxElevation() float64 {
+	return p.TCA.Elevation
+}
+
+// Options controls the pass search
+type Options struct {
+	Start        time.Time     // Window start
+	Stop         time.Time     // Window stop
+	MinElevation float64       // Minimum elevation [degree], applied on top of the observer's horizon mask
+	Step         time.Duration // Coarse sampling interval, DefaultStep when zero
+
+	Optical  bool              // Classify optical visibility and find visible segments
+	Twilight observer.Twilight // Sky darkness required for optical visibility
+
+	StandardMagnitude float64 // Magnitude at 1000 km and 90 degrees phase, used with Optical (see brightness.Magnitudes)
+}
+
+// finder evaluates the elevation of one satellite above the observer's mask
+type finder struct {
+	propagator *orbital.Propagator
+	station    *observer.Station
+	opts       Options
+}
+
+// look returns the look angles at t
+func (f *finder) look(t time.Time) model.LookAngle {
+	return f.station.Look(f.propagator, t)
+}
+
+// aboveMask returns how far the satellite is above the elevation mask at t [degree].
+// The mask is the higher of MinElevation and the observer's horizon in the
+// satellite's direction.
+func (f *finder) aboveMask(t time.Time) float64 {
+	look := f.look(t)
+	return look.Elevation - math.Max(f.opts.MinElevation, f.station.HorizonAt(look.Azimuth))
+}
+
+// elevation returns the elevation at t [degree]
+func (f *finder) elevation(t time.Time) float64 {
+	return f.look(t).Elevation
+}
+
+// event returns the pass event at t
+func (f *finder) event(t time.Time) Event {
+	look := f.look(t)
+	event := Event{Time: look.Time, Azimuth: look.Azimuth, Elevation: look.Elevation}
+	if f.opts.Optical {
+		event.Magnitude, _ = brightness.Estimate(f.propagator, f.station, t, f.opts.StandardMagnitude)
+	}
+	return event
+}
+
+// FindPasses finds every pass of the satellite above the observer's elevation mask
+// (see Options.MinElevation) within the window. Rise and set times are located by
+// bisection and culmination by golden-section search, both to within Tolerance.
+func FindPasses(name string, p *orbital.Propagator, station *observer.Station, opts Options) ([]Pass, error) {
+	if opts.Stop.Before(opts.Start) {
+		return nil, errors.New("stop time is before start time")
+	}
+	if opts.Step <= 0 {
+		opts.Step = DefaultStep
+	}
+
+	f := &finder{propagator: p, station: station, opts: opts}
+	var result []Pass
+
+	// Rise time of the pass in progress, if any
+	var rise time.Time
+	inPass := false
+
+	t0 := opts.Start
+	h0 := f.aboveMask(t0)
+	if h0 > 0 {
+		rise, inPass = t0, true
+	}
+
+	// Previous sample, used to detect elevation peaks between samples
+	tPrev, hPrev := t0, math.Inf(-1)
+
+	for t0.Before(opts.Stop) {
+		t1 := t0.Add(opts.Step)
+		if t1.After(opts.Stop) {
+			t1 = opts.Stop
+		}
+		h1 := f.aboveMask(t1)
+
+		switch {
+		case h0 <= 0 && h1 > 0:
+			// Rising through the mask
+			rise, inPass = f.crossing(t0, t1, f.aboveMask), true
+
+		case h0 > 0 && h1 <= 0:
+			// Setting through the mask
+			set := f.crossing(t0, t1, f.aboveMask)
+			result = append(result, f.newPass(name, rise, set))
+			inPass = false
+
+		case h0 <= 0 && h1 <= 0 && hPrev < h0 && h0 > h1:
+			// A peak between tPrev and t1 that did not show in the samples
+			// may still rise briefly above the mask
+			peak := f.maximum(tPrev, t1, f.aboveMask)
+			if f.aboveMask(peak) > 0 {
+				rise := f.crossing(tPrev, peak, f.aboveMask)
+				set := f.crossing(peak, t1, f.aboveMask)
+				result = append(result, f.newPass(name, rise, set))
+			}
+		}
+
+		tPrev, hPrev = t0, h0
+		t0, h0 = t1, h1
+	}
+
+	// Pass still in progress at the end of the window
+	if inPass {
+		result = append(result, f.newPass(name, rise, opts.Stop))
+	}
+
+	return result, nil
+}
+
+// newPass builds a pass between rise and set, locating the culmination
+func (f *finder) newPass(name string, rise, set time.Time) Pass {
+	pass := Pass{
+		Satellite:      name,
+		AOS:            f.event(rise),
+		TCA:            f.event(f.maximum(rise, set, f.elevation)),
+		LOS:            f.event(set),
+		StartTruncated: rise.Equal(f.opts.Start) && f.aboveMask(rise) > 0,
+		EndTruncated:   set.Equal(f.opts.Stop) && f.aboveMask(set) > 0,
+	}
+	if f.opts.Optical {
+		f.classify(&pass)
+	}
+	return pass
+}
+
+// crossing finds the time in [t0, t1] at which fn changes sign, assuming the sign
+// differs at the two ends
+func (f *finder) crossing(t0, t1 time.Time, fn func(time.Time) float64) time.Time {
+	rising := fn(t0) <= 0
+	for t1.Sub(t0) > Tolerance {
+		mid := t0.Add(t1.Sub(t0) / 2)
+		if (fn(mid) > 0) == rising {
+			t1 = mid
+		} else {
+			t0 = mid
+		}
+	}
+	return t0.Add(t1.Sub(t0) / 2)
+}
+
+// maximum finds the time at which fn is largest in [t0, t1] by golden-section search
+func (f *finder) maximum(t0, t1 time.Time, fn func(time.Time) float64) time.Time {
+	const invPhi = 0.6180339887498949
+
+	a, b := 0.0, t1.Sub(t0).Seconds()
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+
+	c := b - invPhi*(b-a)
+	d := a + invPhi*(b-a)
+	hc, hd := fn(at(c)), fn(at(d))
+	for b-a > Tolerance.Seconds() {
+		if hc > hd {
+			b, d, hd = d, c, hc
+			c = b - invPhi*(b-a)
+			hc = fn(at(c))
+		} else {
+			a, c, hc = c, d, hd
+			d = a + invPhi*(b-a)
+			hd = fn(at(d))
+		}
+	}
+	return at((a + b) / 2)
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"starlink/pkg/model"
 )
 
 func FetchStarlinkTLEData() (string, error) {
@@ -113,3 +115,44 @@ func GetSatelliteAndLines(tleData string) map[string][]string {
 
 	return result
 }
+
+// ParseCatalog parses every satellite in TLE data, preserving the order of the data
+func ParseCatalog(tleData string) []model.Satellite {
+	lines := strings.Split(tleData, "\n")
+	var satellites []model.Satellite
+
+	// TLE format consists of three lines per satellite: name, line1, line2
+	for i := 0; i < len(lines)-2; i++ {
+		// Check for TLE lines pattern
+		if strings.HasPrefix(lines[i+1], "1 ") &&
+			strings.HasPrefix(lines[i+2], "2 ") {
+			satName := strings.TrimSpace(lines[i])
+			if satName != "" {
+				satellites = append(satellites, model.Satellite{
+					Name:     satName,
+					Elements: ParseTleFromStrings(lines[i+1], lines[i+2]),
+				})
+			}
+			// Skip the next two lines since we already processed them
+			i += 2
+		}
+	}
+
+	return satellites
+}
+
+// FindSatellites looks up and parses the named satellites in TLE data
+func FindSatellites(tleData string, satelliteNames []string) ([]model.Satellite, error) {
+	satellites := make([]model.Satellite, 0, len(satelliteNames))
+	for _, name := range satelliteNames {
+		line1, line2, err := FindSatelliteByName(tleData, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		satellites = append(satellites, model.Satellite{
+			Name:     name,
+			Elements: ParseTleFromStrings(line1, line2),
+		})
+	}
+	return satellites, nil
+}
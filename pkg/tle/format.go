@@ -0,0 +1,116 @@
+package tle
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"starlink/pkg/model"
+)
+
+// DefaultElementNumber is the element set number written by FormatTle, marking the
+// element set as locally generated
+const DefaultElementNumber = 999
+
+// FormatTle formats an element set as the two lines of a TLE with checksums. The
+// classification is unclassified, the second derivative of the mean motion zero and
+// the revolution number at epoch is given by revolution.
+func FormatTle(sat *model.TleOrbitalElement, revolution int) (string, string) {
+	// Example: 1 44714U 19074B   25117.42924319 -.00001157  00000+0 -58773-4 0  9990
+	line1 := fmt.Sprintf("1 %5sU %-8s %02d%012.8f %s %s %s 0 %4d",
+		formatCatalogNumber(sat.CatalogNumber), sat.InternationalDesignator,
+		sat.EtYear%100, sat.EtDay, formatMeanMotionDot(sat.MeanMotionDot),
+		formatAssumedDecimal(0), formatAssumedDecimal(sat.BStar), DefaultElementNumber)
+
+	// Example: 2 44714  53.0517 166.3609 0001116  99.1558 260.9557 15.06400606301084
+	eccentricity := int(math.Round(sat.Eccentricity * 1e7))
+	line2 := fmt.Sprintf("2 %5s %8.4f %8.4f %07d %8.4f %8.4f %11.8f%5d",
+		formatCatalogNumber(sat.CatalogNumber), sat.OrbitalInclination, sat.Raan,
+		min(eccentricity, 9999999), sat.ArgumentOfPerigee, sat.MeanAnomaly,
+		sat.MeanMotion, revolution%100000)
+
+	return line1 + fmt.Sprint(Checksum(line1)), line2 + fmt.Sprint(Checksum(line2))
+}
+
+// Checksum returns the modulo-10 checksum of the first 68 columns of a TLE line:
+// the sum of the digits, counting each minus sign as one
+func Checksum(line string) int {
+	sum := 0
+	for i, c := range line {
+		if i >= 68 {
+			break
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
+
+// ValidChecksum reports whether a TLE line ends with the correct checksum
+func ValidChecksum(line string) bool {
+	line = strings.TrimRight(line, " \r")
+	if len(line) != 69 {
+		return false
+	}
+	return int(line[68]-'0') == Checksum(line)
+}
+
+// formatCatalogNumber right-aligns a catalog number in five columns, using 99999 for
+// element sets without one
+func formatCatalogNumber(number string) string {
+	if number == "" {
+		return "99999"
+	}
+	return fmt.Sprintf("%5s", number)
+}
+
+// formatMeanMotionDot formats the first derivative of the mean motion in ten columns
+// with a sign and no leading zero, e.g. "-.00001157"
+func formatMeanMotionDot(value float64) string {
+	sign := " "
+	if value < 0 {
+		sign = "-"
+	}
+	digits := fmt.Sprintf("%.8f", math.Min(math.Abs(value), 0.99999999))
+	return sign + strings.TrimPrefix(digits, "0")
+}
+
+// formatAssumedDecimal formats a value with an assumed leading decimal point and a
+// signed exponent in eight columns, the inverse of parseAssumedDecimal,
+// e.g. -0.58773e-4 is "-58773-4"
+func formatAssumedDecimal(value float64) string {
+	sign := " "
+	if value < 0 {
+		sign = "-"
+	}
+	value = math.Abs(value)
+	if value < 1e-10 {
+		return " 00000+0"
+	}
+
+	exponent := int(math.Floor(math.Log10(value))) + 1
+	mantissa := int(math.Round(value / math.Pow(10, float64(exponent)) * 1e5))
+	if mantissa >= 100000 {
+		mantissa /= 10
+		exponent++
+	}
+	exponent = max(min(exponent, 9), -9)
+
+	expSign := "+"
+	if exponent < 0 {
+		expSign = "-"
+	}
+	return fmt.Sprintf("%s%05d%s%d", sign, mantissa, expSign, abs(exponent))
+}
+
+// abs returns the absolute value of an integer
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
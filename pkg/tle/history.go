@@ -0,0 +1,54 @@
+package tle
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"starlink/pkg/model"
+)
+
+// LoadHistory reads a file of element sets, such as the TLE history of one or more objects
+func LoadHistory(path string) ([]model.Satellite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLE history: %w", err)
+	}
+
+	satellites := ParseHistory(string(data))
+	if len(satellites) == 0 {
+		return nil, fmt.Errorf("no element sets in %s", path)
+	}
+	return satellites, nil
+}
+
+// ParseHistory parses every element set in TLE data in either the two-line or the
+// three-line format, preserving the order of the data. Unlike ParseCatalog the name
+// line is optional, as in history downloads; element sets without one are named
+// after their catalog number. A leading "0 " on name lines (Space-Track 3LE) is dropped.
+func ParseHistory(tleData string) []model.Satellite {
+	lines := strings.Split(strings.ReplaceAll(tleData, "\r\n", "\n"), "\n")
+	var satellites []model.Satellite
+
+	name := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		// Both lines must be complete, as ParseTleFromStrings reads up to the checksum
+		if i+1 < len(lines) && len(line) >= 69 && len(lines[i+1]) >= 69 &&
+			strings.HasPrefix(line, "1 ") && strings.HasPrefix(lines[i+1], "2 ") {
+			elements := ParseTleFromStrings(line, lines[i+1])
+			if name == "" {
+				name = elements.CatalogNumber
+			}
+			satellites = append(satellites, model.Satellite{Name: name, Elements: elements})
+			name = ""
+			i++ // Skip line 2
+			continue
+		}
+
+		// Any other non-empty line names the element set that follows it
+		name = strings.TrimSpace(strings.TrimPrefix(line, "0 "))
+	}
+
+	return satellites
+}
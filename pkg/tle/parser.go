@@ -1,6 +1,7 @@
 package tle
 
 import (
+	"math"
 	"strconv"
 	"strings"
 
@@ -54,8 +55,9 @@ func ParseTleFromStrings(str1, str2 string) *model.TleOrbitalElement {
 	// Second Time Derivative of Mean Motion (decimal point assumed)
 	secondTimeDerivativeOfTheMeanMotion := strings.TrimSpace(str1[44:52])
 
-	// B* drag term
+	// B* drag term (decimal point and exponent assumed)
 	bstarDragTerm := strings.TrimSpace(str1[53:61])
+	bstar := parseAssumedDecimal(bstarDragTerm)
 
 	// Element number and checksum
 	elementnum := strings.TrimSpace(str1[64:68])
@@ -106,7 +108,44 @@ func ParseTleFromStrings(str1, str2 string) *model.TleOrbitalElement {
 		OrbitalInclination: orbitalInclination,
 		Raan:               rightAscensionOfAscendingNode,
 		ArgumentOfPerigee:  argumentOfPerigee,
+		BStar:              bstar,
+
+		CatalogNumber:           satelliteNumber,
+		InternationalDesignator: internationalDesignator,
+	}
+}
+
+// parseAssumedDecimal parses TLE fields written with an assumed leading
+// decimal point and a signed exponent, e.g. "-58773-4" means -0.58773e-4
+func parseAssumedDecimal(field string) float64 {
+	field = strings.TrimSpace(field)
+	if len(field) < 2 {
+		return 0
+	}
+
+	sign := 1.0
+	if field[0] == '-' || field[0] == '+' {
+		if field[0] == '-' {
+			sign = -1.0
+		}
+		field = field[1:]
 	}
+
+	// The exponent is the trailing signed digit(s)
+	expPos := strings.LastIndexAny(field, "+-")
+	if expPos <= 0 {
+		return 0
+	}
+	mantissa, err := strconv.ParseFloat("0."+strings.TrimSpace(field[:expPos]), 64)
+	if err != nil {
+		return 0
+	}
+	exponent, err := strconv.Atoi(field[expPos:])
+	if err != nil {
+		return 0
+	}
+
+	return sign * mantissa * math.Pow(10, float64(exponent))
 }
 
 // PrintTleParameters outputs all TLE parameters in a readable format
@@ -0,0 +1,369 @@
+package tlefit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+
+	"starlink/pkg/decay"
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+	"starlink/pkg/orbital"
+	"starlink/pkg/vecmath"
+)
+
+// Default iteration limits of the differential correction
+const (
+	DefaultMaxIterations = 25
+	DefaultTolerance     = 1e-6
+)
+
+// Options configure a fit. The zero value fits the six mean elements at the time of
+// the first state.
+type Options struct {
+	Epoch         time.Time // Epoch of the fitted element set, the first state's time when zero
+	FitDrag       bool      // Also solve for the mean motion derivative, and derive B* from it
+	MaxIterations int       // Iteration limit, DefaultMaxIterations when zero
+	Tolerance     float64   // Relative change of the RMS residual that ends the iteration, DefaultTolerance when zero
+}
+
+// Statistics summarize the residuals (observed minus fitted) of a fit
+type Statistics struct {
+	Samples       int     // Number of states
+	RMSPosition   float64 // RMS of the position residual magnitude [km]
+	MaxPosition   float64 // Largest position residual [km]
+	RMSVelocity   float64 // RMS of the velocity residual magnitude [km/s]
+	RMSRadial     float64 // RMS of the radial position residual [km]
+	RMSInTrack    float64 // RMS of the in-track position residual [km]
+	RMSCrossTrack float64 // RMS of the cross-track position residual [km]
+}
+
+// Result is a fitted element set with its residual statistics
+type Result struct {
+	Elements   *model.TleOrbitalElement // Fitted mean elements
+	Iterations int                      // Gauss-Newton iterations performed
+	Converged  bool                     // Whether the relative change of the RMS residual fell below the tolerance
+	Statistics Statistics               // Residuals of the fitted element set
+}
+
+// Solve-for parameters: equinoctial elements with the retrograde factor I, which stay
+// defined for the circular and equatorial orbits where the argument of perigee or the
+// node are not. I is 1, or -1 for orbits inclined beyond 90°, so that the equatorial
+// orbits of both directions are covered.
+const (
+	paramMeanMotion    = iota // n [Rev/Day]
+	paramEccentricityK        // e cos(ω + I Ω) [-]
+	paramEccentricityH        // e sin(ω + I Ω) [-]
+	paramInclinationQ         // tan(i/2)^I cos Ω [-]
+	paramInclinationP         // tan(i/2)^I sin Ω [-]
+	paramLongitude            // M + ω + I Ω [Degree]
+	paramMeanMotionDot        // dn/dt, see orbital.MeanMotionRate [Rev/Day2]
+)
+
+// perturbations are the steps of the central differences of each parameter
+var perturbations = [...]float64{1e-8, 1e-7, 1e-7, 1e-8, 1e-8, 1e-6, 1e-9}
+
+// basis is what the parameters are applied to: the element set supplying the epoch,
+// the identification and the terms not solved for, and the retrograde factor
+type basis struct {
+	sat        model.TleOrbitalElement
+	retrograde float64 // Retrograde factor I of the equinoctial parameters
+}
+
+// maxHalvings limits the step halving when an iteration increases the residual
+const maxHalvings = 10
+
+// Residuals returns the observed minus computed residuals of the element set that p
+// propagates, weighted to make them comparable, e.g. divided by the measurement noise
+type Residuals func(p *orbital.Propagator) []float64
+
+// Fit finds the mean elements whose analytic propagation best matches the equatorial
+// states in a weighted least-squares sense, by batch differential correction. Velocity
+// residuals are weighted by the inverse mean motion, so that they count like the
+// position errors they cause over a radian of the orbit. The propagator models drag
+// only through the mean motion derivative, which is therefore the drag parameter
+// solved for with FitDrag; B* is then derived from it with the decay model's ballistic
+// coefficient in the default solar-flux atmosphere. Otherwise both are taken from
+// template. The catalog number and international designator are copied from template,
+// which may be nil.
+func Fit(states []model.StateVector, template *model.TleOrbitalElement, opts Options) (Result, error) {
+	if len(states) < 2 {
+		return Result{}, errors.New("at least two states are needed for a fit")
+	}
+	states = append([]model.StateVector(nil), states...)
+	sort.Slice(states, func(i, j int) bool { return states[i].Time.Before(states[j].Time) })
+
+	if opts.Epoch.IsZero() {
+		opts.Epoch = states[0].Time
+	}
+	guess := states[0]
+	for _, s := range states[1:] {
+		if s.Time.Sub(opts.Epoch).Abs() < guess.Time.Sub(opts.Epoch).Abs() {
+			guess = s
+		}
+	}
+
+	result, err := Correct(guess, template, stateResiduals(states), opts)
+	if err != nil {
+		return result, err
+	}
+	result.Statistics = statistics(states, result.Elements)
+	return result, nil
+}
+
+// Correct finds the mean elements that minimize the residuals in a least-squares sense
+// by batch differential correction (Gauss-Newton with step halving), starting from the
+// osculating state guess. The epoch defaults to the time of the guess; the other
+// options and template are used as by Fit. The statistics of the result are left
+// empty, as only the caller knows the units of its residuals.
+func Correct(guess model.StateVector, template *model.TleOrbitalElement, residualsOf Residuals, opts Options) (Result, error) {
+	if opts.Epoch.IsZero() {
+		opts.Epoch = guess.Time
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultMaxIterations
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+
+	base := basis{retrograde: 1}
+	if template != nil {
+		base.sat = *template
+	}
+	base.sat.EtYear, base.sat.EtDay = orbital.EpochFields(opts.Epoch)
+	if orbital.StateToKeplerian(guess).Inclination > 90 {
+		base.retrograde = -1
+	}
+
+	count := paramMeanMotionDot
+	if opts.FitDrag {
+		count++
+	}
+	params := initialGuess(guess, opts.Epoch, orbital.MeanMotionRate(&base.sat), base.retrograde)
+
+	residuals, err := evaluate(base, params, residualsOf)
+	if err != nil {
+		return Result{}, fmt.Errorf("initial guess: %w", err)
+	}
+	if len(residuals) < count {
+		return Result{}, fmt.Errorf("%d residuals cannot determine %d parameters", len(residuals), count)
+	}
+	cost := rms(residuals)
+
+	result := Result{}
+	for result.Iterations < opts.MaxIterations {
+		result.Iterations++
+
+		jacobian, err := jacobianMatrix(base, params, count, residualsOf)
+		if err != nil {
+			return Result{}, err
+		}
+		var step mat.VecDense
+		if err := step.SolveVec(jacobian, mat.NewVecDense(len(residuals), residuals)); err != nil {
+			var condition mat.Condition
+			if !errors.As(err, &condition) {
+				return Result{}, fmt.Errorf("iteration %d: %w", result.Iterations, err)
+			}
+		}
+
+		// Gauss-Newton step, halved until it reduces the residual
+		improved := false
+		scale := 1.0
+		for halving := 0; halving <= maxHalvings; halving++ {
+			trial := params
+			for i := 0; i < count; i++ {
+				trial[i] += scale * step.AtVec(i)
+			}
+			trialResiduals, err := evaluate(base, trial, residualsOf)
+			if err == nil && rms(trialResiduals) <= cost {
+				params, residuals = trial, trialResiduals
+				improved = true
+				break
+			}
+			scale /= 2
+		}
+		if !improved {
+			break // The residual cannot be reduced along the step, without having settled
+		}
+
+		previous := cost
+		cost = rms(residuals)
+		if previous-cost <= opts.Tolerance*previous {
+			result.Converged = true
+			break
+		}
+	}
+
+	result.Elements, _ = elements(base, params)
+	if opts.FitDrag && result.Elements != nil {
+		// From the fitted dn/dt itself; B* of one that shows no decay is zero
+		result.Elements.BStar, _ = decay.BStar(params[paramMeanMotion], params[paramMeanMotionDot], numerical.SolarFlux{})
+	}
+	return result, nil
+}
+
+// initialGuess converts an osculating state to parameters with the retrograde factor,
+// moving the mean anomaly to the epoch with the Keplerian mean motion
+func initialGuess(guess model.StateVector, epoch time.Time, meanMotionDot, retrograde float64) [7]float64 {
+	el := orbital.StateToKeplerian(guess)
+	meanMotion := el.Properties().MeanMotion
+	days := guess.Time.Sub(epoch).Hours() / 24
+	raan := el.Raan * math.Pi / 180
+	perigee := el.ArgumentOfPerigee*math.Pi/180 + retrograde*raan
+	tanHalfI := math.Pow(math.Tan(el.Inclination*math.Pi/360), retrograde)
+
+	return [7]float64{
+		paramMeanMotion:    meanMotion,
+		paramEccentricityK: el.Eccentricity * math.Cos(perigee),
+		paramEccentricityH: el.Eccentricity * math.Sin(perigee),
+		paramInclinationQ:  tanHalfI * math.Cos(raan),
+		paramInclinationP:  tanHalfI * math.Sin(raan),
+		paramLongitude:     perigee*180/math.Pi + el.MeanAnomaly() - 360*meanMotion*days,
+		paramMeanMotionDot: meanMotionDot,
+	}
+}
+
+// elements builds the element set of a parameter vector on top of base
+func elements(base basis, params [7]float64) (*model.TleOrbitalElement, error) {
+	sat := base.sat
+	sat.MeanMotion = params[paramMeanMotion]
+	orbital.SetMeanMotionRate(&sat, params[paramMeanMotionDot])
+	sat.Eccentricity = math.Hypot(params[paramEccentricityK], params[paramEccentricityH])
+
+	// The inclination follows from tan(i/2)^I, which is never negative, so it stays
+	// within 0-180° whatever the step
+	halfI := math.Atan(math.Hypot(params[paramInclinationQ], params[paramInclinationP]))
+	if base.retrograde < 0 {
+		halfI = math.Pi/2 - halfI
+	}
+	raan := math.Atan2(params[paramInclinationP], params[paramInclinationQ])
+	perigee := math.Atan2(params[paramEccentricityH], params[paramEccentricityK])
+	sat.OrbitalInclination = 2 * halfI * 180 / math.Pi
+	sat.Raan = normalize(raan * 180 / math.Pi)
+	sat.ArgumentOfPerigee = normalize((perigee - base.retrograde*raan) * 180 / math.Pi)
+	sat.MeanAnomaly = normalize(params[paramLongitude] - perigee*180/math.Pi)
+
+	if err := orbital.ValidateElements(&sat); err != nil {
+		return nil, err
+	}
+	return &sat, nil
+}
+
+// evaluate returns the residuals of the element set of a parameter vector
+func evaluate(base basis, params [7]float64, residualsOf Residuals) ([]float64, error) {
+	sat, err := elements(base, params)
+	if err != nil {
+		return nil, err
+	}
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		return nil, err
+	}
+	return residualsOf(p), nil
+}
+
+// stateResiduals returns the observed minus computed position [km] and weighted
+// velocity residuals of every state
+func stateResiduals(states []model.StateVector) Residuals {
+	return func(p *orbital.Propagator) []float64 {
+		weight := 86400 / (2 * math.Pi * p.Elements().MeanMotion)
+
+		residuals := make([]float64, 0, 6*len(states))
+		for _, observed := range states {
+			computed := p.StateECI(observed.Time)
+			residuals = append(residuals,
+				observed.X-computed.X, observed.Y-computed.Y, observed.Z-computed.Z,
+				weight*(observed.VX-computed.VX), weight*(observed.VY-computed.VY), weight*(observed.VZ-computed.VZ))
+		}
+		return residuals
+	}
+}
+
+// jacobianMatrix returns the partial derivatives of the computed values with respect
+// to the first count parameters, by central differences
+func jacobianMatrix(base basis, params [7]float64, count int, residualsOf Residuals) (*mat.Dense, error) {
+	var jacobian *mat.Dense
+	for j := 0; j < count; j++ {
+		plus, minus := params, params
+		plus[j] += perturbations[j]
+		minus[j] -= perturbations[j]
+
+		// Residuals are observed minus computed, so their difference is reversed
+		residualsPlus, err := evaluate(base, plus, residualsOf)
+		if err != nil {
+			return nil, fmt.Errorf("partial derivatives: %w", err)
+		}
+		residualsMinus, err := evaluate(base, minus, residualsOf)
+		if err != nil {
+			return nil, fmt.Errorf("partial derivatives: %w", err)
+		}
+		if jacobian == nil {
+			jacobian = mat.NewDense(len(residualsPlus), count, nil)
+		}
+		for i := range residualsPlus {
+			jacobian.Set(i, j, (residualsMinus[i]-residualsPlus[i])/(2*perturbations[j]))
+		}
+	}
+	return jacobian, nil
+}
+
+// statistics computes the residual statistics of a fitted element set
+func statistics(states []model.StateVector, sat *model.TleOrbitalElement) Statistics {
+	stats := Statistics{Samples: len(states)}
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		return stats
+	}
+
+	var position, velocity, radial, inTrack, crossTrack float64
+	for _, observed := range states {
+		computed := p.StateECI(observed.Time)
+		r := vecmath.Vec3{X: computed.X, Y: computed.Y, Z: computed.Z}
+		v := vecmath.Vec3{X: computed.VX, Y: computed.VY, Z: computed.VZ}
+		dr := vecmath.Vec3{X: observed.X - computed.X, Y: observed.Y - computed.Y, Z: observed.Z - computed.Z}
+		dv := vecmath.Vec3{X: observed.VX - computed.VX, Y: observed.VY - computed.VY, Z: observed.VZ - computed.VZ}
+
+		radialAxis := r.Unit()
+		crossAxis := r.Cross(v).Unit()
+		inTrackAxis := crossAxis.Cross(radialAxis)
+
+		norm := dr.Norm()
+		stats.MaxPosition = math.Max(stats.MaxPosition, norm)
+		position += norm * norm
+		velocity += dv.Dot(dv)
+		radial += math.Pow(dr.Dot(radialAxis), 2)
+		inTrack += math.Pow(dr.Dot(inTrackAxis), 2)
+		crossTrack += math.Pow(dr.Dot(crossAxis), 2)
+	}
+
+	n := float64(len(states))
+	stats.RMSPosition = math.Sqrt(position / n)
+	stats.RMSVelocity = math.Sqrt(velocity / n)
+	stats.RMSRadial = math.Sqrt(radial / n)
+	stats.RMSInTrack = math.Sqrt(inTrack / n)
+	stats.RMSCrossTrack = math.Sqrt(crossTrack / n)
+	return stats
+}
+
+// rms returns the root mean square of a residual vector
+func rms(residuals []float64) float64 {
+	sum := 0.0
+	for _, r := range residuals {
+		sum += r * r
+	}
+	return math.Sqrt(sum / float64(len(residuals)))
+}
+
+// normalize wraps an angle into [0, 360) degrees
+func normalize(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
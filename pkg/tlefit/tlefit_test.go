@@ -0,0 +1,102 @@
+package tlefit_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"starlink/pkg/model"
+	"starlink/pkg/numerical"
+	"starlink/pkg/orbital"
+	"starlink/pkg/tlefit"
+	"starlink/pkg/vecmath"
+)
+
+// states samples the analytic propagation of an element set every step for a day
+func states(t *testing.T, sat *model.TleOrbitalElement, step time.Duration) []model.StateVector {
+	t.Helper()
+	p, err := orbital.NewPropagator(sat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []model.StateVector
+	for dt := time.Duration(0); dt <= 24*time.Hour; dt += step {
+		result = append(result, p.StateECI(p.Epoch().Add(dt)))
+	}
+	return result
+}
+
+// TestFitBoundaryInclinations fits element sets on the inclination bounds, where the
+// node is undefined
+func TestFitBoundaryInclinations(t *testing.T) {
+	for _, inclination := range []float64{0, 1e-7, 53, 97.6, 179.9999999, 180} {
+		sat := &model.TleOrbitalElement{
+			MeanMotion:         15.06,
+			Eccentricity:       0.0001,
+			OrbitalInclination: inclination,
+			Raan:               40,
+			ArgumentOfPerigee:  90,
+			MeanAnomaly:        10,
+			EtYear:             2025,
+			EtDay:              117.5,
+		}
+		result, err := tlefit.Fit(states(t, sat, 10*time.Minute), nil, tlefit.Options{})
+		if err != nil {
+			t.Errorf("inclination %g°: %v", inclination, err)
+			continue
+		}
+		if result.Statistics.RMSPosition > 1e-3 {
+			t.Errorf("inclination %g°: RMS position %.6f km", inclination, result.Statistics.RMSPosition)
+		}
+	}
+}
+
+// inertialDrag is drag in a non-rotating atmosphere, the model of the decay calibration
+// that derives B*
+type inertialDrag struct {
+	atmosphere numerical.Atmosphere
+	ballistic  float64 // C_D A / m [m2/kg]
+}
+
+func (d inertialDrag) Acceleration(t time.Time, position, velocity vecmath.Vec3) vecmath.Vec3 {
+	density := d.atmosphere.Density(t, position)
+	return velocity.Scale(-0.5 * d.ballistic * density * 1000 * velocity.Norm())
+}
+
+// TestFitDragBStar fits a day of a circular equatorial orbit at 300 km decayed
+// numerically with a known ballistic coefficient, and checks the fitted rate of change
+// of the mean motion and the B* derived from it
+func TestFitDragBStar(t *testing.T) {
+	const ballistic = 0.02                     // C_D A / m [m2/kg]
+	const wantBStar = 2.461e-5 * ballistic / 2 // B* = rho_0 B / 2 [1/EarthRadii]
+	field, _ := numerical.ZonalField(2)
+	forces := []numerical.Force{numerical.Gravity{Field: field}, inertialDrag{numerical.SolarFlux{}, ballistic}}
+
+	epoch := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	r := 6378.137 + 300
+	propagator := numerical.NewPropagator(model.StateVector{Time: epoch, X: r, VY: math.Sqrt(orbital.EarthMu / r)},
+		forces, numerical.Options{})
+	var observed []model.StateVector
+	for dt := time.Duration(0); dt <= 24*time.Hour; dt += time.Minute {
+		observed = append(observed, propagator.StateECI(epoch.Add(dt)))
+	}
+
+	// The observed dn/dt over the day [Rev/Day2]
+	meanMotion := func(state model.StateVector) float64 {
+		return orbital.StateToKeplerian(state).Properties().MeanMotion // [Rev/Day]
+	}
+	wantRate := (meanMotion(observed[len(observed)-1]) - meanMotion(observed[0]))
+
+	result, err := tlefit.Fit(observed, nil, tlefit.Options{FitDrag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate := orbital.MeanMotionRate(result.Elements); math.Abs(rate/wantRate-1) > 0.05 {
+		t.Errorf("fitted dn/dt %.4e rev/day2, want %.4e", rate, wantRate)
+	}
+	// The semi-major axis of the fitted mean motion is a few km off the orbit's, which
+	// at a density scale height of 23 km moves B* by about 10%
+	if bStar := result.Elements.BStar; math.Abs(bStar/wantBStar-1) > 0.15 {
+		t.Errorf("B* %.4e, want %.4e", bStar, wantBStar)
+	}
+}
@@ -5,6 +5,14 @@ import "math"
 // EarthRadius is the Earth's radius in kilometers
 const EarthRadius = 6356.752
 
+// WGS-84 reference ellipsoid, used to place ground observers
+const (
+	// WGS84EquatorialRadius is the semi-major axis of the WGS-84 ellipsoid [km]
+	WGS84EquatorialRadius = 6378.137
+	// WGS84Flattening is the flattening of the WGS-84 ellipsoid [-]
+	WGS84Flattening = 1 / 298.257223563
+)
+
 // Deg2Rad converts degrees to radians
 func Deg2Rad(deg float64) float64 {
 	return deg / 180.0 * math.Pi
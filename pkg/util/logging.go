@@ -41,6 +41,12 @@ func GetLogLevelFromEnv() {
 	}
 }
 
+// IsDebugEnabled reports whether debug messages are printed. Hot paths check it
+// before calling LogDebug so that formatting arguments are not evaluated needlessly.
+func IsDebugEnabled() bool {
+	return CurrentLogLevel >= LogLevelDebug
+}
+
 // LogDebug prints a message if the current log level is Debug or higher
 func LogDebug(format string, args ...interface{}) {
 	if CurrentLogLevel >= LogLevelDebug {
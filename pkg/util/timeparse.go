@@ -0,0 +1,78 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JulianDateUnixEpoch is the Julian date of 1970-01-01T00:00:00Z
+const JulianDateUnixEpoch = 2440587.5
+
+// JulianDate returns the Julian date of t
+func JulianDate(t time.Time) float64 {
+	return JulianDateUnixEpoch + float64(t.UnixNano())/(86400.0*1e9)
+}
+
+// TimeFromJulianDate converts a Julian date to a UTC time
+func TimeFromJulianDate(jd float64) time.Time {
+	nanos := (jd - JulianDateUnixEpoch) * 86400.0 * 1e9
+	return time.Unix(0, int64(nanos)).UTC()
+}
+
+// ParseTime parses a time given in one of the following forms:
+//
+//	now                     the reference time
+//	2025-04-28T12:00:00Z    RFC3339
+//	JD2460793.5             Julian date
+//	1745841600              Unix seconds (fractions allowed), optionally prefixed with @
+//	+90m, -2h, +1.5d        offset from the reference time
+func ParseTime(value string, ref time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case value == "":
+		return time.Time{}, fmt.Errorf("empty time")
+
+	case strings.EqualFold(value, "now"):
+		return ref, nil
+
+	case strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"):
+		offset, err := parseOffset(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time offset %q: %w", value, err)
+		}
+		return ref.Add(offset), nil
+
+	case len(value) > 2 && strings.EqualFold(value[:2], "JD"):
+		jd, err := strconv.ParseFloat(strings.TrimLeft(value[2:], " :"), 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid Julian date %q: %w", value, err)
+		}
+		return TimeFromJulianDate(jd), nil
+	}
+
+	// Unix seconds
+	if seconds, err := strconv.ParseFloat(strings.TrimPrefix(value, "@"), 64); err == nil {
+		return time.Unix(0, int64(seconds*1e9)).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognised time %q (expected RFC3339, JD<date>, Unix seconds or an offset like +90m)", value)
+	}
+	return t, nil
+}
+
+// parseOffset parses a signed Go duration, additionally accepting a day suffix such as "-1.5d"
+func parseOffset(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(value, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * 24 * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(value)
+}
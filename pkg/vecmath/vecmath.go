@@ -0,0 +1,134 @@
+package vecmath
+
+import "math"
+
+// Vec3 is a three-component vector passed by value
+type Vec3 struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// Add returns v + w
+func (v Vec3) Add(w Vec3) Vec3 {
+	return Vec3{v.X + w.X, v.Y + w.Y, v.Z + w.Z}
+}
+
+// Sub returns v - w
+func (v Vec3) Sub(w Vec3) Vec3 {
+	return Vec3{v.X - w.X, v.Y - w.Y, v.Z - w.Z}
+}
+
+// Scale returns s * v
+func (v Vec3) Scale(s float64) Vec3 {
+	return Vec3{s * v.X, s * v.Y, s * v.Z}
+}
+
+// Dot returns the scalar product of v and w
+func (v Vec3) Dot(w Vec3) float64 {
+	return v.X*w.X + v.Y*w.Y + v.Z*w.Z
+}
+
+// Cross returns the vector product v x w
+func (v Vec3) Cross(w Vec3) Vec3 {
+	return Vec3{
+		v.Y*w.Z - v.Z*w.Y,
+		v.Z*w.X - v.X*w.Z,
+		v.X*w.Y - v.Y*w.X,
+	}
+}
+
+// Norm returns the Euclidean length of v
+func (v Vec3) Norm() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y + v.Z*v.Z)
+}
+
+// Unit returns v scaled to unit length, or the zero vector if v is zero
+func (v Vec3) Unit() Vec3 {
+	n := v.Norm()
+	if n == 0 {
+		return Vec3{}
+	}
+	return v.Scale(1 / n)
+}
+
+// Mat3 is a 3x3 matrix stored by value in row-major order
+type Mat3 [9]float64
+
+// Identity returns the 3x3 identity matrix
+func Identity() Mat3 {
+	return Mat3{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1}
+}
+
+// Diagonal returns the diagonal matrix with the given diagonal elements
+func Diagonal(x, y, z float64) Mat3 {
+	return Mat3{
+		x, 0, 0,
+		0, y, 0,
+		0, 0, z}
+}
+
+// Rows returns the matrix whose rows are the given vectors
+func Rows(a, b, c Vec3) Mat3 {
+	return Mat3{
+		a.X, a.Y, a.Z,
+		b.X, b.Y, b.Z,
+		c.X, c.Y, c.Z}
+}
+
+// RotX returns the matrix rotating a vector by angle [Rad] about the x axis
+func RotX(angle float64) Mat3 {
+	s, c := math.Sincos(angle)
+	return Mat3{
+		1, 0, 0,
+		0, c, -s,
+		0, s, c}
+}
+
+// RotZ returns the matrix rotating a vector by angle [Rad] about the z axis
+func RotZ(angle float64) Mat3 {
+	s, c := math.Sincos(angle)
+	return Mat3{
+		c, -s, 0,
+		s, c, 0,
+		0, 0, 1}
+}
+
+// Add returns the element-wise sum m + n
+func (m Mat3) Add(n Mat3) Mat3 {
+	for i := range m {
+		m[i] += n[i]
+	}
+	return m
+}
+
+// Mul returns the matrix product m * n
+func (m Mat3) Mul(n Mat3) Mat3 {
+	var r Mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[3*i+j] = m[3*i]*n[j] + m[3*i+1]*n[3+j] + m[3*i+2]*n[6+j]
+		}
+	}
+	return r
+}
+
+// MulVec returns the matrix-vector product m * v
+func (m Mat3) MulVec(v Vec3) Vec3 {
+	return Vec3{
+		m[0]*v.X + m[1]*v.Y + m[2]*v.Z,
+		m[3]*v.X + m[4]*v.Y + m[5]*v.Z,
+		m[6]*v.X + m[7]*v.Y + m[8]*v.Z,
+	}
+}
+
+// Transpose returns the transpose of m, which is its inverse for rotation matrices
+func (m Mat3) Transpose() Mat3 {
+	return Mat3{
+		m[0], m[3], m[6],
+		m[1], m[4], m[7],
+		m[2], m[5], m[8]}
+}